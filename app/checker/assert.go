@@ -0,0 +1,51 @@
+package checker
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+// DefaultMaxBodySize is the limit of the response body read for assertions
+const DefaultMaxBodySize = 1 << 20
+
+// BodyAssertion checks the response body of the target, every condition must pass
+type BodyAssertion struct {
+	Contains    []string
+	NotContains []string
+	Regex       []*regexp.Regexp
+	MaxSize     int64 // bytes of body to read, DefaultMaxBodySize if 0
+}
+
+// Empty reports whether there is nothing to check and the body can be skipped
+func (b BodyAssertion) Empty() bool {
+	return len(b.Contains) == 0 && len(b.NotContains) == 0 && len(b.Regex) == 0
+}
+
+// Limit returns the max size of body to read
+func (b BodyAssertion) Limit() int64 {
+	if b.MaxSize > 0 {
+		return b.MaxSize
+	}
+	return DefaultMaxBodySize
+}
+
+// Check returns the error describing the first failed assertion
+func (b BodyAssertion) Check(body []byte) error {
+	for _, s := range b.Contains {
+		if !bytes.Contains(body, []byte(s)) {
+			return fmt.Errorf("body doesn't contain %q", s)
+		}
+	}
+	for _, s := range b.NotContains {
+		if bytes.Contains(body, []byte(s)) {
+			return fmt.Errorf("body contains %q", s)
+		}
+	}
+	for _, re := range b.Regex {
+		if !re.Match(body) {
+			return fmt.Errorf("body doesn't match %q", re.String())
+		}
+	}
+	return nil
+}
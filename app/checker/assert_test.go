@@ -0,0 +1,23 @@
+package checker
+
+import (
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+)
+
+func TestBodyAssertionCheck(t *testing.T) {
+	b := BodyAssertion{
+		Contains:    []string{"<title>Shop</title>"},
+		NotContains: []string{"Database error"},
+		Regex:       []*regexp.Regexp{regexp.MustCompile(`version: \d+\.\d+`)},
+	}
+	assert.False(t, b.Empty())
+	assert.Equal(t, int64(DefaultMaxBodySize), b.Limit())
+
+	assert.NoError(t, b.Check([]byte("<title>Shop</title> version: 1.2")))
+	assert.EqualError(t, b.Check([]byte("<title>Oops</title>")), `body doesn't contain "<title>Shop</title>"`)
+	assert.EqualError(t, b.Check([]byte("<title>Shop</title> Database error")), `body contains "Database error"`)
+	assert.EqualError(t, b.Check([]byte("<title>Shop</title> version: x")), `body doesn't match "version: \\d+\\.\\d+"`)
+	assert.True(t, BodyAssertion{MaxSize: 10}.Empty())
+}
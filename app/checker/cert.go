@@ -0,0 +1,70 @@
+package checker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultExpiryDays is how many days before the leaf certificate expiry it is alerted
+const DefaultExpiryDays = 14
+
+// kinds of certificate problem, the alert is sent again only if the kind is changed
+const (
+	certExpiring   = "expiring"
+	certUnverified = "unverified"
+	certHostname   = "hostname"
+)
+
+// Cert is the peer certificate inspected by HTTPS probe
+type Cert struct {
+	Subject  string
+	Issuer   string
+	NotAfter time.Time
+	Problem  string // empty for valid certificate
+	kind     string
+}
+
+// failsHandshake reports whether the problem fails the probe as well, unlike the expiring certificate
+func (c *Cert) failsHandshake() bool {
+	return c != nil && (c.kind == certUnverified || c.kind == certHostname)
+}
+
+// inspectCert checks the leaf of the verified chain for the expiry
+func inspectCert(state *tls.ConnectionState, expiryDays int, now time.Time) *Cert {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+	leaf := state.PeerCertificates[0]
+	c := &Cert{Subject: leaf.Subject.CommonName, Issuer: leaf.Issuer.CommonName, NotAfter: leaf.NotAfter}
+	if expiryDays <= 0 {
+		expiryDays = DefaultExpiryDays
+	}
+	if left := leaf.NotAfter.Sub(now); left < time.Duration(expiryDays)*24*time.Hour {
+		c.kind = certExpiring
+		c.Problem = fmt.Sprintf("certificate %s expires in %d day(s) at %s",
+			c.Subject, int(left.Hours()/24), leaf.NotAfter.Format("2006-01-02 15:04:05 MST"))
+	}
+	return c
+}
+
+// certFromError makes the certificate problem from the handshake error, nil if err is not about certificate
+func certFromError(err error) *Cert {
+	var verr *tls.CertificateVerificationError
+	if !errors.As(err, &verr) {
+		return nil
+	}
+	c := &Cert{kind: certUnverified, Problem: fmt.Sprintf("certificate chain doesn't verify: %v", verr.Err)}
+	if len(verr.UnverifiedCertificates) > 0 {
+		leaf := verr.UnverifiedCertificates[0]
+		c.Subject, c.Issuer, c.NotAfter = leaf.Subject.CommonName, leaf.Issuer.CommonName, leaf.NotAfter
+	}
+	var herr x509.HostnameError
+	if errors.As(verr.Err, &herr) {
+		c.kind = certHostname
+		c.Problem = fmt.Sprintf("certificate hostname mismatch: %v", herr)
+	}
+	return c
+}
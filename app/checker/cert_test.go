@@ -0,0 +1,94 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/theshamuel/hhchecker/app/provider"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProbeCert(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	s := &Scheduler{Client: ts.Client()}
+
+	r := s.probe(context.Background(), Target{Name: "a", URL: ts.URL})
+	assert.True(t, r.OK())
+	assert.NotNil(t, r.Cert)
+	assert.Empty(t, r.Cert.Problem)
+	assert.False(t, r.Cert.NotAfter.IsZero())
+
+	r = s.probe(context.Background(), Target{Name: "a", URL: ts.URL, TLS: TLS{ExpiryDays: 40000}})
+	assert.True(t, r.OK())
+	assert.Equal(t, certExpiring, r.Cert.kind)
+	assert.Contains(t, r.Cert.Problem, "expires in")
+
+	r = s.probe(context.Background(), Target{Name: "a", URL: strings.Replace(ts.URL, "127.0.0.1", "localhost", 1)})
+	assert.False(t, r.OK())
+	assert.Equal(t, certHostname, r.Cert.kind)
+	assert.Contains(t, r.Cert.Problem, "certificate hostname mismatch")
+
+	s.Client = &http.Client{}
+	r = s.probe(context.Background(), Target{Name: "a", URL: ts.URL})
+	assert.False(t, r.OK())
+	assert.Equal(t, certUnverified, r.Cert.kind)
+	assert.Contains(t, r.Cert.Problem, "certificate chain doesn't verify")
+
+	r = s.probe(context.Background(), Target{Name: "a", URL: "http://127.0.0.1:1"})
+	assert.Nil(t, r.Cert, "no handshake")
+}
+
+func TestCheckCert(t *testing.T) {
+	mock := &mockProvider{}
+	s := &Scheduler{Providers: []provider.Interface{mock}}
+	target := Target{Name: "a", MaxAlerts: 1}
+	st := &state{}
+	expiry := time.Now().Add(24 * time.Hour)
+	expiring := Result{StatusCode: 200, Time: time.Now(),
+		Cert: &Cert{NotAfter: expiry, Problem: "certificate expires in 1 day(s)", kind: certExpiring}}
+
+	s.check(context.Background(), target, st, expiring)
+	s.check(context.Background(), target, st, expiring)
+	sent := mock.notifications()
+	assert.Len(t, sent, 1, "the same problem is alerted once")
+	assert.Equal(t, provider.TypeCertificate, sent[0].Type)
+	assert.Equal(t, provider.StateDown, sent[0].State)
+	assert.Equal(t, expiry, sent[0].CertExpiry)
+
+	s.check(context.Background(), target, st, Result{StatusCode: 200, Time: time.Now(), Cert: &Cert{NotAfter: expiry.AddDate(1, 0, 0)}})
+	sent = mock.notifications()
+	assert.Len(t, sent, 2)
+	assert.Equal(t, provider.TypeCertificate, sent[1].Type)
+	assert.Equal(t, provider.StateRecovered, sent[1].State)
+	assert.Empty(t, st.certKind)
+}
+
+func TestCheckCertHandshakeAlertedOnce(t *testing.T) {
+	mock := &mockProvider{}
+	s := &Scheduler{Providers: []provider.Interface{mock}}
+	target := Target{Name: "a", MaxAlerts: 1}
+	st := &state{}
+	mismatch := Result{Err: errors.New("x509: certificate is valid for example.com"), Time: time.Now(),
+		Cert: &Cert{Problem: "certificate hostname mismatch", kind: certHostname}}
+	s.start(target)
+
+	for i := 0; i < 3; i++ {
+		s.check(context.Background(), target, st, mismatch)
+	}
+	sent := mock.notifications()
+	assert.Len(t, sent, 1, "the handshake failure is alerted as certificate only")
+	assert.Equal(t, provider.TypeCertificate, sent[0].Type)
+	status, _ := s.Status("a")
+	assert.Equal(t, 3, status.Failed, "the probes are still failed")
+
+	s.check(context.Background(), target, st, Result{StatusCode: 200, Time: time.Now(), Cert: &Cert{}})
+	sent = mock.notifications()
+	assert.Len(t, sent, 2, "only certificate recovery")
+	assert.Equal(t, provider.TypeCertificate, sent[1].Type)
+	assert.Equal(t, provider.StateRecovered, sent[1].State)
+}
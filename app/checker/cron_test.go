@@ -0,0 +1,59 @@
+package checker
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// Thursday
+	now := time.Date(2026, 10, 15, 10, 30, 15, 0, time.UTC)
+	tbl := []struct {
+		expr string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2026, 10, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1", time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)},
+		{"30 8,18 * * *", time.Date(2026, 10, 15, 18, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 1 *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)}, // the 13th or Friday
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tbl {
+		s, err := ParseSchedule(tt.expr)
+		assert.NoError(t, err, tt.expr)
+		assert.Equal(t, tt.next, s.Next(now), tt.expr)
+	}
+
+	s, err := ParseSchedule("0 9 * * *")
+	assert.NoError(t, err)
+	dublin, err := time.LoadLocation("Europe/Dublin")
+	if err == nil {
+		assert.Equal(t, time.Date(2026, 10, 16, 9, 0, 0, 0, dublin), s.Next(now.In(dublin)), "local time of the location")
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	tbl := []struct {
+		expr string
+		err  string
+	}{
+		{"0 9 * *", `cron expression "0 9 * *" has 4 fields instead of 5`},
+		{"60 * * * *", `cron expression "60 * * * *" is not valid: "60" is out of range 0-59`},
+		{"* 5-1 * * *", `cron expression "* 5-1 * * *" is not valid: "5-1" is out of range 0-23`},
+		{"*/0 * * * *", `cron expression "*/0 * * * *" is not valid: step of "*/0" is not a positive number`},
+		{"* * * * mon", `cron expression "* * * * mon" is not valid: "mon" is not a number`},
+		{"0 0 30 2 *", `cron expression "0 0 30 2 *" never fires`},
+	}
+	for _, tt := range tbl {
+		_, err := ParseSchedule(tt.expr)
+		assert.EqualError(t, err, tt.err)
+	}
+}
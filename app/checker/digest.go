@@ -0,0 +1,149 @@
+package checker
+
+import (
+	"context"
+	"github.com/theshamuel/hhchecker/app/provider"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DigestTop is the max count of the slowest targets and expiring certificates in the digest
+const DigestTop = 5
+
+// Digest is the summary of all targets sent by every provider on the schedule
+type Digest struct {
+	Title    string // the digest is sent as the alert with the title as target
+	Schedule Schedule
+}
+
+// period collects probes of every target since the previous digest
+type period struct {
+	since   time.Time
+	targets map[string]*provider.DigestTarget
+}
+
+// periods keeps the period of every digest by its index
+type periods struct {
+	sync.Mutex
+	byDigest []*period
+}
+
+// reset starts the periods of count digests
+func (p *periods) reset(count int, now time.Time) {
+	p.Lock()
+	defer p.Unlock()
+	p.byDigest = make([]*period, count)
+	for i := range p.byDigest {
+		p.byDigest[i] = &period{since: now, targets: map[string]*provider.DigestTarget{}}
+	}
+}
+
+// observe counts the probe into the period of every digest
+func (p *periods) observe(name string, r Result) {
+	p.Lock()
+	defer p.Unlock()
+	for _, pr := range p.byDigest {
+		t := pr.targets[name]
+		if t == nil {
+			t = &provider.DigestTarget{Name: name}
+			pr.targets[name] = t
+		}
+		t.Checks++
+		if !r.OK() {
+			t.Failed++
+		}
+		if r.Latency > t.Slowest {
+			t.Slowest = r.Latency
+		}
+	}
+}
+
+// take returns the period of the digest and starts the next one
+func (p *periods) take(i int, now time.Time) period {
+	p.Lock()
+	defer p.Unlock()
+	res := *p.byDigest[i]
+	p.byDigest[i] = &period{since: now, targets: map[string]*provider.DigestTarget{}}
+	return res
+}
+
+// digests sends every digest on its schedule until ctx is done
+func (s *Scheduler) digests(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i, d := range s.Digests {
+		wg.Add(1)
+		go func(i int, d Digest) {
+			defer wg.Done()
+			log.Printf("[INFO] digest %s is scheduled by %s", d.Title, d.Schedule.Expr)
+			for {
+				timer := time.NewTimer(time.Until(d.Schedule.Next(time.Now())))
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+				s.sendDigest(ctx, i, time.Now())
+			}
+		}(i, d)
+	}
+	wg.Wait()
+}
+
+// sendDigest sends the digest for the period since the previous one by all providers
+func (s *Scheduler) sendDigest(ctx context.Context, i int, now time.Time) {
+	log.Printf("[INFO] sending digest %s", s.Digests[i].Title)
+	s.notify(ctx, s.digest(s.Digests[i].Title, s.periods.take(i, now), now))
+}
+
+// digest makes the digest alert of the period. Checks are counted by the probes of the period,
+// incidents by the history if it is set, otherwise by the status incidents.
+// Certificate expiry is taken from the last probe.
+func (s *Scheduler) digest(title string, p period, now time.Time) provider.Alert {
+	d := provider.Digest{From: p.since, To: now}
+	for _, t := range s.Targets {
+		st, ok := s.Status(t.Name)
+		if !ok {
+			continue
+		}
+		dt := provider.DigestTarget{Name: t.Name}
+		if c := p.targets[t.Name]; c != nil {
+			dt = *c
+		}
+		r := NewReport(t.Name, nil, st.Incidents, p.since, now)
+		if s.History != nil {
+			if days, incidents, err := s.History.Load(t.Name); err != nil {
+				log.Printf("[WARN] can't load history of target %s: %v", t.Name, err)
+			} else {
+				r = NewReport(t.Name, days, incidents, p.since, now)
+			}
+		}
+		dt.Uptime = Report{Checks: dt.Checks, Failed: dt.Failed}.Uptime()
+		dt.Incidents = r.Incidents
+		dt.Slowest = dt.Slowest.Round(time.Millisecond)
+		if st.Last.Cert != nil {
+			dt.CertExpiry = st.Last.Cert.NotAfter
+		}
+		d.Targets = append(d.Targets, dt)
+	}
+
+	for _, dt := range d.Targets {
+		if dt.Slowest > 0 {
+			d.Slowest = append(d.Slowest, dt)
+		}
+		if !dt.CertExpiry.IsZero() {
+			d.Expiring = append(d.Expiring, dt)
+		}
+	}
+	sort.SliceStable(d.Slowest, func(i, j int) bool { return d.Slowest[i].Slowest > d.Slowest[j].Slowest })
+	sort.SliceStable(d.Expiring, func(i, j int) bool { return d.Expiring[i].CertExpiry.Before(d.Expiring[j].CertExpiry) })
+	if len(d.Slowest) > DigestTop {
+		d.Slowest = d.Slowest[:DigestTop]
+	}
+	if len(d.Expiring) > DigestTop {
+		d.Expiring = d.Expiring[:DigestTop]
+	}
+	return provider.Alert{Target: title, Type: provider.TypeDigest, Time: now, StartedAt: p.since, Digest: d}
+}
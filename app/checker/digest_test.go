@@ -0,0 +1,76 @@
+package checker
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/theshamuel/hhchecker/app/provider"
+	"testing"
+	"time"
+)
+
+func TestSendDigest(t *testing.T) {
+	api := Target{Name: "api", MaxAlerts: 1}
+	blog := Target{Name: "blog", MaxAlerts: 1}
+	mock := &mockProvider{}
+	s := &Scheduler{Targets: []Target{api, blog, {Name: "idle"}}, Providers: []provider.Interface{mock},
+		Digests: []Digest{{Title: "Daily"}}}
+	since := time.Now().Add(-time.Hour)
+	s.periods.reset(1, since)
+	s.start(api)
+	s.start(blog)
+
+	expiry := time.Now().AddDate(0, 0, 40)
+	apiSt, blogSt := &state{}, &state{}
+	s.check(context.Background(), api, apiSt, Result{StatusCode: 200, Latency: 300 * time.Millisecond, Time: time.Now()})
+	s.check(context.Background(), api, apiSt, failed)
+	s.check(context.Background(), api, apiSt, failed)
+	s.check(context.Background(), blog, blogSt, Result{StatusCode: 200, Latency: 1200 * time.Millisecond,
+		Cert: &Cert{NotAfter: expiry}, Time: time.Now()})
+	s.check(context.Background(), blog, blogSt, Result{StatusCode: 200, Latency: 20 * time.Millisecond,
+		Cert: &Cert{NotAfter: expiry}, Time: time.Now()})
+
+	now := time.Now()
+	s.sendDigest(context.Background(), 0, now)
+	sent := mock.notifications()
+	assert.Len(t, sent, 2, "down alert and digest")
+	a := sent[1]
+	assert.Equal(t, provider.TypeDigest, a.Type)
+	assert.Equal(t, "Daily", a.Target)
+	assert.Equal(t, since, a.Digest.From)
+	assert.Equal(t, now, a.Digest.To)
+	assert.Equal(t, []provider.DigestTarget{
+		{Name: "api", Uptime: Report{Checks: 3, Failed: 2}.Uptime(), Checks: 3, Failed: 2, Incidents: 1, Slowest: 300 * time.Millisecond},
+		{Name: "blog", Uptime: 100, Checks: 2, Slowest: 1200 * time.Millisecond, CertExpiry: expiry},
+	}, a.Digest.Targets, "unwatched target is skipped")
+	assert.Equal(t, []string{"blog", "api"}, []string{a.Digest.Slowest[0].Name, a.Digest.Slowest[1].Name})
+	assert.Len(t, a.Digest.Expiring, 1)
+
+	s.sendDigest(context.Background(), 0, now.Add(time.Hour))
+	sent = mock.notifications()
+	assert.Equal(t, now, sent[2].Digest.From, "the next period starts at the previous digest")
+	assert.Equal(t, 0, sent[2].Digest.Targets[1].Checks)
+	assert.Equal(t, float64(100), sent[2].Digest.Targets[1].Uptime)
+	assert.Equal(t, 1, sent[2].Digest.Targets[0].Incidents, "the ongoing incident overlaps the period")
+}
+
+func TestSendDigestHistory(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	history := &memHistory{
+		days:      []Day{{Date: today, Checks: 200, Failed: 2}, {Date: today.AddDate(0, 0, -10), Checks: 500, Failed: 500}},
+		incidents: []Incident{{Target: "api", Started: time.Now().Add(-10 * time.Minute), Resolved: time.Now()}},
+	}
+	api := Target{Name: "api", MaxAlerts: 1}
+	mock := &mockProvider{}
+	s := &Scheduler{Targets: []Target{api}, Providers: []provider.Interface{mock}, History: history,
+		Digests: []Digest{{Title: "Daily"}}}
+	s.periods.reset(1, time.Now().Add(-time.Hour))
+	s.start(api)
+	s.check(context.Background(), api, &state{}, Result{StatusCode: 200, Latency: 300 * time.Millisecond, Time: time.Now()})
+
+	s.sendDigest(context.Background(), 0, time.Now())
+	sent := mock.notifications()
+	assert.Len(t, sent, 1)
+	assert.Equal(t, []provider.DigestTarget{
+		{Name: "api", Uptime: 100, Checks: 1, Incidents: 1, Slowest: 300 * time.Millisecond},
+	}, sent[0].Digest.Targets, "checks are counted by the period, incidents by history")
+}
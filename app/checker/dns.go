@@ -0,0 +1,217 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DNSCheck describes the queried record and the assertions on its answers, the answer must not be empty
+type DNSCheck struct {
+	Record        string   // one of A, AAAA, CNAME, MX, TXT, A if empty
+	Resolver      string   // host:port of the name server, the system resolver if empty, port 53 by default
+	Contains      []string // every value must be in the answer
+	Equals        []string // the answer must be exactly this set of values
+	AlertOnChange bool     // the answer different from the previous one is sent as the notice
+}
+
+var dnsRecords = map[string]bool{"A": true, "AAAA": true, "CNAME": true, "MX": true, "TXT": true}
+
+func (d DNSCheck) record() string {
+	if d.Record == "" {
+		return "A"
+	}
+	return strings.ToUpper(d.Record)
+}
+
+// Validate checks the record type and the resolver address
+func (d DNSCheck) Validate() error {
+	if !dnsRecords[d.record()] {
+		return fmt.Errorf("dns record %q is not one of A, AAAA, CNAME, MX, TXT", d.Record)
+	}
+	if d.Resolver == "" {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(d.resolver()); err != nil {
+		return fmt.Errorf("dns resolver %q is not valid: %w", d.Resolver, err)
+	}
+	return nil
+}
+
+// resolver returns the resolver address with the default port
+func (d DNSCheck) resolver() string {
+	if _, _, err := net.SplitHostPort(d.Resolver); err != nil {
+		return net.JoinHostPort(d.Resolver, "53")
+	}
+	return d.Resolver
+}
+
+// lookup queries the record of the host and returns the normalized values
+func (d DNSCheck) lookup(ctx context.Context, host string) ([]string, error) {
+	r := net.DefaultResolver
+	if d.Resolver != "" {
+		addr := d.resolver()
+		r = &net.Resolver{PreferGo: true, Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, addr)
+		}}
+	}
+	res, err := d.query(ctx, r, host)
+	var derr *net.DNSError
+	if errors.As(err, &derr) && d.Resolver != "" {
+		derr.Server = d.resolver() // the go resolver reports the system name server
+	}
+	return res, err
+}
+
+func (d DNSCheck) query(ctx context.Context, r *net.Resolver, host string) ([]string, error) {
+	var res []string
+	switch d.record() {
+	case "A", "AAAA":
+		network := "ip4"
+		if d.record() == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := r.LookupIP(ctx, network, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			res = append(res, ip.String())
+		}
+	case "CNAME":
+		cname, err := r.LookupCNAME(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		if dnsName(cname) != dnsName(host) { // the resolver returns the queried name if there is no CNAME record
+			res = append(res, dnsName(cname))
+		}
+	case "MX":
+		mxs, err := r.LookupMX(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			res = append(res, dnsName(mx.Host))
+		}
+	case "TXT":
+		txts, err := r.LookupTXT(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, txts...)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// check compares the answer with the expected values and describes the drift
+func (d DNSCheck) check(answer []string) error {
+	if len(answer) == 0 {
+		return fmt.Errorf("dns %s answer is empty", d.record())
+	}
+	got := map[string]bool{}
+	for _, v := range answer {
+		got[v] = true
+	}
+	var missing []string
+	for _, v := range append(d.normalize(d.Contains), d.normalize(d.Equals)...) {
+		if !got[v] {
+			missing = append(missing, v)
+		}
+	}
+	var unexpected []string
+	if len(d.Equals) > 0 {
+		want := map[string]bool{}
+		for _, v := range d.normalize(d.Equals) {
+			want[v] = true
+		}
+		for _, v := range answer {
+			if !want[v] {
+				unexpected = append(unexpected, v)
+			}
+		}
+	}
+	if len(missing) == 0 && len(unexpected) == 0 {
+		return nil
+	}
+	drift := []string{}
+	if len(missing) > 0 {
+		drift = append(drift, fmt.Sprintf("missing %s", strings.Join(missing, ", ")))
+	}
+	if len(unexpected) > 0 {
+		drift = append(drift, fmt.Sprintf("unexpected %s", strings.Join(unexpected, ", ")))
+	}
+	return fmt.Errorf("dns %s answer [%s] drifted: %s", d.record(), strings.Join(answer, ", "), strings.Join(drift, "; "))
+}
+
+// normalize makes the expected names comparable with the answer
+func (d DNSCheck) normalize(values []string) []string {
+	res := make([]string, 0, len(values))
+	for _, v := range values {
+		switch d.record() {
+		case "CNAME", "MX":
+			v = dnsName(v)
+		case "A", "AAAA":
+			if ip := net.ParseIP(v); ip != nil {
+				v = ip.String()
+			}
+		}
+		res = append(res, v)
+	}
+	return res
+}
+
+func dnsName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// dnsAnswers keeps the last answer of every target and record
+type dnsAnswers struct {
+	sync.Mutex
+	last map[string][]string
+}
+
+// swap stores the answer and returns the previous one, nil if there was none
+func (d *dnsAnswers) swap(key string, answer []string) []string {
+	d.Lock()
+	defer d.Unlock()
+	if d.last == nil {
+		d.last = map[string][]string{}
+	}
+	prev := d.last[key]
+	d.last[key] = answer
+	return prev
+}
+
+// probeDNS queries the record of the target host, checks the answer and compares it with the previous one
+// if the check alerts on change
+func (s *Scheduler) probeDNS(ctx context.Context, t Target) Result {
+	r := Result{Time: time.Now()}
+	ctx, cancel := context.WithTimeout(ctx, t.timeout())
+	defer cancel()
+	answer, err := t.DNS.lookup(ctx, t.URL)
+	r.Timings.DNS = time.Since(r.Time)
+	r.Timings.Total, r.Latency = r.Timings.DNS, r.Timings.DNS
+	if err != nil {
+		log.Printf("[DEBUG] target %s get error: %v", t.Name, err)
+		r.Err = t.probeError(err)
+		return r
+	}
+	log.Printf("[DEBUG] target %s get dns %s answer: %v", t.Name, t.DNS.record(), answer)
+	if r.Err = t.DNS.check(answer); r.Err != nil || !t.DNS.AlertOnChange {
+		return r
+	}
+	prev := s.answers.swap(t.Name+" "+t.DNS.record(), answer)
+	if prev != nil && strings.Join(prev, "\n") != strings.Join(answer, "\n") {
+		r.change = fmt.Sprintf("dns %s answer changed from [%s] to [%s]", t.DNS.record(), strings.Join(prev, ", "), strings.Join(answer, ", "))
+	}
+	return r
+}
@@ -0,0 +1,155 @@
+package checker
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/theshamuel/hhchecker/app/provider"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestProbeDNS(t *testing.T) {
+	addr := startDNSStub(t, map[uint16][][]byte{
+		1:  {{10, 0, 0, 1}, {10, 0, 0, 2}},
+		15: {append([]byte{0, 10}, dnsEncodeName("MX1.example.test.")...)},
+		16: {append([]byte{11}, "v=spf1 -all"...)},
+	})
+	s := &Scheduler{}
+	target := Target{Name: "dns", Type: TypeDNS, URL: "app.example.test", DNS: DNSCheck{Resolver: addr}}
+
+	r := s.probe(context.Background(), target)
+	assert.True(t, r.OK(), r.Reason())
+
+	target.DNS.Equals = []string{"10.0.0.2", "10.0.0.1"}
+	r = s.probe(context.Background(), target)
+	assert.True(t, r.OK(), r.Reason())
+
+	target.DNS.Equals = []string{"10.0.0.1", "10.0.0.3"}
+	r = s.probe(context.Background(), target)
+	assert.EqualError(t, r.Err, "dns A answer [10.0.0.1, 10.0.0.2] drifted: missing 10.0.0.3; unexpected 10.0.0.2")
+
+	target.DNS = DNSCheck{Resolver: addr, Record: "mx", Contains: []string{"mx1.example.test."}}
+	r = s.probe(context.Background(), target)
+	assert.True(t, r.OK(), r.Reason())
+
+	target.DNS = DNSCheck{Resolver: addr, Record: "TXT", Contains: []string{"v=spf1 -all"}}
+	r = s.probe(context.Background(), target)
+	assert.True(t, r.OK(), r.Reason())
+
+	target.DNS = DNSCheck{Resolver: addr, Record: "AAAA"}
+	r = s.probe(context.Background(), target)
+	assert.False(t, r.OK(), "no AAAA records")
+	assert.Contains(t, r.Reason(), addr, "the configured resolver is reported")
+
+	target.DNS = DNSCheck{Resolver: addr, Record: "CNAME"}
+	r = s.probe(context.Background(), target)
+	assert.EqualError(t, r.Err, "dns CNAME answer is empty", "the queried name itself is not the record")
+}
+
+func TestProbeDNSAnswerChanged(t *testing.T) {
+	addr := startDNSStub(t, map[uint16][][]byte{1: {{10, 0, 0, 1}}, 16: {append([]byte{2}, "ok"...)}})
+	moved := startDNSStub(t, map[uint16][][]byte{1: {{10, 0, 0, 2}}})
+	mock := &mockProvider{}
+	s := &Scheduler{Providers: []provider.Interface{mock}}
+	target := Target{Name: "dns", Type: TypeDNS, URL: "app.example.test", MaxAlerts: 1, DNS: DNSCheck{Resolver: addr}}
+	st := &state{}
+	s.start(target)
+
+	probe := func() Result {
+		r := s.probe(context.Background(), target)
+		s.check(context.Background(), target, st, r)
+		return r
+	}
+	assert.True(t, probe().OK())
+	target.DNS.Resolver = moved
+	r := probe()
+	assert.True(t, r.OK(), r.Reason())
+	assert.Empty(t, mock.notifications(), "the change is not alerted by default")
+
+	target.DNS = DNSCheck{Resolver: addr, AlertOnChange: true}
+	assert.True(t, probe().OK())
+	assert.True(t, probe().OK())
+	target.DNS.Resolver = moved
+	r = probe()
+	assert.True(t, r.OK(), "the change is not a failure")
+	sent := mock.notifications()
+	assert.Len(t, sent, 1, "the change is sent as the notice")
+	assert.Equal(t, provider.TypeDNS, sent[0].Type)
+	assert.Equal(t, provider.StateChanged, sent[0].State)
+	assert.Equal(t, "dns A answer changed from [10.0.0.1] to [10.0.0.2]", sent[0].Reason)
+
+	assert.True(t, probe().OK())
+	assert.Len(t, mock.notifications(), 1, "the changed answer is accepted without recovery")
+	status, _ := s.Status("dns")
+	assert.Empty(t, status.Incidents)
+	assert.Equal(t, 0, status.Failed)
+
+	target.DNS = DNSCheck{Resolver: addr, Record: "txt", AlertOnChange: true}
+	assert.True(t, probe().OK())
+	assert.Len(t, mock.notifications(), 1, "the first answer of the record is not a change")
+}
+
+func TestDNSCheckValidate(t *testing.T) {
+	assert.NoError(t, DNSCheck{}.Validate())
+	assert.NoError(t, DNSCheck{Record: "cname", Resolver: "1.1.1.1"}.Validate())
+	assert.Equal(t, "1.1.1.1:53", DNSCheck{Resolver: "1.1.1.1"}.resolver())
+	assert.Equal(t, "[::1]:53", DNSCheck{Resolver: "::1"}.resolver())
+	assert.EqualError(t, DNSCheck{Record: "SRV"}.Validate(), `dns record "SRV" is not one of A, AAAA, CNAME, MX, TXT`)
+	assert.EqualError(t, DNSCheck{}.check(nil), "dns A answer is empty")
+}
+
+// startDNSStub answers the queries of any name by records of the query type, the empty answer for unknown types
+func startDNSStub(t *testing.T, records map[uint16][][]byte) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n < 12 {
+				continue
+			}
+			// the question name ends by zero label followed by type and class
+			end := 12
+			for end < n && buf[end] != 0 {
+				end += int(buf[end]) + 1
+			}
+			if end+5 > n {
+				continue
+			}
+			question := buf[12 : end+5]
+			qtype := binary.BigEndian.Uint16(buf[end+1:])
+			res := append([]byte{}, buf[:2]...)
+			res = append(res, 0x81, 0x80, 0, 1)
+			res = binary.BigEndian.AppendUint16(res, uint16(len(records[qtype])))
+			res = append(res, 0, 0, 0, 0)
+			res = append(res, question...)
+			for _, rdata := range records[qtype] {
+				res = append(res, 0xc0, 12) // pointer to the question name
+				res = binary.BigEndian.AppendUint16(res, qtype)
+				res = append(res, 0, 1, 0, 0, 0, 60)
+				res = binary.BigEndian.AppendUint16(res, uint16(len(rdata)))
+				res = append(res, rdata...)
+			}
+			_, _ = conn.WriteTo(res, from)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func dnsEncodeName(name string) []byte {
+	var res []byte
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		res = append(res, byte(len(label)))
+		res = append(res, label...)
+	}
+	return append(res, 0)
+}
@@ -0,0 +1,211 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// GRPCCheck describes the call of grpc.health.v1.Health/Check, the target url is host:port
+type GRPCCheck struct {
+	Service   string // the checked service name, the whole server if empty
+	Plaintext bool   // h2c without TLS, otherwise TLS settings of the target are used
+}
+
+// serving statuses of grpc.health.v1.HealthCheckResponse
+var grpcServingStatus = map[uint64]string{0: "UNKNOWN", 1: "SERVING", 2: "NOT_SERVING", 3: "SERVICE_UNKNOWN"}
+
+const grpcHealthPath = "/grpc.health.v1.Health/Check"
+
+// Validate checks the target is host:port with the optional grpc:// or grpcs:// scheme
+func (g GRPCCheck) Validate(target string) error {
+	_, err := g.url(target)
+	return err
+}
+
+// grpcClient returns the copy of scheduler client forced to HTTP/2 with the target TLS settings or h2c
+func (s *Scheduler) grpcClient(t Target) (*http.Client, error) {
+	transport, ok := s.Client.Transport.(*http.Transport)
+	if !ok || transport == nil {
+		transport = http.DefaultTransport.(*http.Transport)
+	}
+	client := *s.Client
+	if t.GRPC.Plaintext {
+		client.Transport = h2cTransport(transport)
+		return &client, nil
+	}
+	transport = transport.Clone()
+	cfg, err := t.TLS.Config(transport.TLSClientConfig)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = cfg
+	transport.ForceAttemptHTTP2 = true
+	client.Transport = transport
+	return &client, nil
+}
+
+// url returns the url of health check method for host:port, grpc:// and grpcs:// schemes are allowed
+func (g GRPCCheck) url(target string) (string, error) {
+	addr := strings.TrimPrefix(strings.TrimPrefix(target, "grpc://"), "grpcs://")
+	if _, err := Address(addr); err != nil {
+		return "", err
+	}
+	if g.Plaintext {
+		return "http://" + addr + grpcHealthPath, nil
+	}
+	return "https://" + addr + grpcHealthPath, nil
+}
+
+// probeGRPC calls the health check and fails unless the service is SERVING
+func (s *Scheduler) probeGRPC(ctx context.Context, t Target) Result {
+	r := Result{Time: time.Now()}
+	ctx, cancel := context.WithTimeout(ctx, t.timeout())
+	defer cancel()
+	url, err := t.GRPC.url(t.URL)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	client := t.client
+	if client == nil {
+		if client, err = s.grpcClient(t); err != nil {
+			r.Err = err
+			return r
+		}
+	}
+	tr := newTracer(r.Time)
+	body := grpcFrame(grpcHealthRequest(t.GRPC.Service))
+	req, err := http.NewRequestWithContext(tr.context(ctx), http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	response, err := client.Do(req)
+	if err != nil {
+		r.Timings = tr.result()
+		r.Latency = r.Timings.Total
+		log.Printf("[DEBUG] target %s get error: %v", t.Name, err)
+		r.Err = t.probeError(err)
+		r.Cert = certFromError(err)
+		return r
+	}
+	defer response.Body.Close()
+	r.Cert = inspectCert(response.TLS, t.TLS.ExpiryDays, r.Time)
+	r.StatusCode = response.StatusCode
+	msg, err := io.ReadAll(io.LimitReader(response.Body, 64*1024))
+	r.Timings = tr.result()
+	r.Latency = r.Timings.Total
+	if err != nil {
+		r.Err = t.probeError(fmt.Errorf("can't read grpc response: %w", err))
+		return r
+	}
+	if response.StatusCode != http.StatusOK {
+		r.Err = fmt.Errorf("bad http status code %d of grpc response", response.StatusCode)
+		return r
+	}
+	if r.Err = grpcStatusError(response); r.Err != nil {
+		return r
+	}
+	status, err := grpcHealthStatus(msg)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	log.Printf("[DEBUG] target %s get grpc health status %s", t.Name, status)
+	if status != "SERVING" {
+		r.Err = fmt.Errorf("grpc health status %s", status)
+	}
+	return r
+}
+
+// grpcStatusError returns the error of non-OK grpc-status, trailers-only response has it in headers
+func grpcStatusError(response *http.Response) error {
+	code, message := response.Trailer.Get("Grpc-Status"), response.Trailer.Get("Grpc-Message")
+	if code == "" {
+		code, message = response.Header.Get("Grpc-Status"), response.Header.Get("Grpc-Message")
+	}
+	if code == "" {
+		return fmt.Errorf("grpc response has no grpc-status")
+	}
+	if code == "0" {
+		return nil
+	}
+	if code == "12" { // UNIMPLEMENTED
+		return fmt.Errorf("grpc health service is not implemented: %s", message)
+	}
+	return fmt.Errorf("grpc status %s: %s", code, message)
+}
+
+// grpcHealthRequest encodes HealthCheckRequest protobuf message with the service field
+func grpcHealthRequest(service string) []byte {
+	if service == "" {
+		return nil
+	}
+	msg := []byte{0x0a} // field 1, length-delimited
+	msg = binary.AppendUvarint(msg, uint64(len(service)))
+	return append(msg, service...)
+}
+
+// grpcFrame prefixes the uncompressed message by its length
+func grpcFrame(msg []byte) []byte {
+	frame := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+	return append(frame, msg...)
+}
+
+// grpcHealthStatus decodes the status field of HealthCheckResponse from the framed message
+func grpcHealthStatus(frame []byte) (string, error) {
+	if len(frame) < 5 {
+		return "", fmt.Errorf("grpc response is empty")
+	}
+	if frame[0] != 0 {
+		return "", fmt.Errorf("grpc response is compressed")
+	}
+	size := binary.BigEndian.Uint32(frame[1:5])
+	if uint32(len(frame)-5) < size {
+		return "", fmt.Errorf("grpc response is truncated")
+	}
+	msg := frame[5 : 5+size]
+	status := uint64(0) // proto3 omits the default UNKNOWN
+	for len(msg) > 0 {
+		key, n := binary.Uvarint(msg)
+		if n <= 0 {
+			return "", errors.New("grpc health response is not valid protobuf")
+		}
+		msg = msg[n:]
+		switch key & 7 {
+		case 0: // varint
+			v, n := binary.Uvarint(msg)
+			if n <= 0 {
+				return "", errors.New("grpc health response is not valid protobuf")
+			}
+			if key>>3 == 1 {
+				status = v
+			}
+			msg = msg[n:]
+		case 2: // length-delimited
+			l, n := binary.Uvarint(msg)
+			if n <= 0 || uint64(len(msg)-n) < l {
+				return "", errors.New("grpc health response is not valid protobuf")
+			}
+			msg = msg[n+int(l):]
+		default:
+			return "", fmt.Errorf("grpc health response has unsupported wire type %d", key&7)
+		}
+	}
+	if s, ok := grpcServingStatus[status]; ok {
+		return s, nil
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", status), nil
+}
@@ -0,0 +1,23 @@
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"golang.org/x/net/http2"
+	"net"
+	"net/http"
+)
+
+// h2cTransport returns HTTP/2 transport without TLS for plaintext grpc, connections are dialed by the given transport
+func h2cTransport(t *http.Transport) http.RoundTripper {
+	dial := t.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	return &http2.Transport{
+		AllowHTTP: true, // prior knowledge HTTP/2 for http:// urls
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return dial(ctx, network, addr)
+		},
+	}
+}
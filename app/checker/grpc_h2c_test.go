@@ -0,0 +1,28 @@
+package checker
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProbeGRPCPlaintext(t *testing.T) {
+	ts := httptest.NewServer(h2c.NewHandler(grpcHealthHandler(t, map[string]byte{"": 1, "down": 2}), &http2.Server{}))
+	defer ts.Close()
+	s := &Scheduler{Client: &http.Client{}}
+
+	target := Target{Name: "grpc", Type: TypeGRPC, URL: strings.TrimPrefix(ts.URL, "http://"), GRPC: GRPCCheck{Plaintext: true}}
+	assert.NoError(t, target.GRPC.Validate(target.URL))
+	r := s.probe(context.Background(), target)
+	assert.True(t, r.OK(), r.Reason())
+	assert.Nil(t, r.Cert)
+
+	target.GRPC.Service = "down"
+	r = s.probe(context.Background(), target)
+	assert.EqualError(t, r.Err, "grpc health status NOT_SERVING")
+}
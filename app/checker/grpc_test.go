@@ -0,0 +1,83 @@
+package checker
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// grpcHealthHandler answers the health check by the status of the requested service
+func grpcHealthHandler(t *testing.T, statuses map[string]byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, 2, r.ProtoMajor)
+		assert.Equal(t, grpcHealthPath, r.URL.Path)
+		assert.Equal(t, "application/grpc", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		service := ""
+		if len(body) > 7 {
+			service = string(body[7:])
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		status, ok := statuses[service]
+		if !ok {
+			w.Header().Set("Grpc-Status", "5")
+			w.Header().Set("Grpc-Message", "unknown service")
+			return
+		}
+		w.Header().Set("Trailer", "Grpc-Status")
+		_, _ = w.Write(grpcFrame([]byte{0x08, status}))
+		w.Header().Set("Grpc-Status", "0")
+	}
+}
+
+func TestProbeGRPC(t *testing.T) {
+	ts := httptest.NewUnstartedServer(grpcHealthHandler(t, map[string]byte{"": 1, "billing": 2}))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+	s := &Scheduler{Client: ts.Client()}
+	addr := strings.TrimPrefix(ts.URL, "https://")
+
+	r := s.probe(context.Background(), Target{Name: "grpc", Type: TypeGRPC, URL: addr})
+	assert.True(t, r.OK(), r.Reason())
+	assert.NotNil(t, r.Cert)
+
+	r = s.probe(context.Background(), Target{Name: "grpc", Type: TypeGRPC, URL: "grpcs://" + addr, GRPC: GRPCCheck{Service: "billing"}})
+	assert.EqualError(t, r.Err, "grpc health status NOT_SERVING")
+
+	r = s.probe(context.Background(), Target{Name: "grpc", Type: TypeGRPC, URL: addr, GRPC: GRPCCheck{Service: "orders"}})
+	assert.EqualError(t, r.Err, "grpc status 5: unknown service")
+
+	r = s.probe(context.Background(), Target{Name: "grpc", Type: TypeGRPC, URL: "billing"})
+	assert.Contains(t, r.Reason(), "is not host:port")
+}
+
+func TestGRPCHealthStatus(t *testing.T) {
+	tbl := []struct {
+		frame  []byte
+		status string
+		err    string
+	}{
+		{grpcFrame([]byte{0x08, 1}), "SERVING", ""},
+		{grpcFrame(nil), "UNKNOWN", ""},
+		{grpcFrame([]byte{0x12, 2, 'a', 'b', 0x08, 3}), "SERVICE_UNKNOWN", ""},
+		{grpcFrame([]byte{0x08, 9}), "UNKNOWN(9)", ""},
+		{[]byte{0, 0, 0, 0, 5, 0x08}, "", "grpc response is truncated"},
+		{[]byte{}, "", "grpc response is empty"},
+		{grpcFrame([]byte{0x08}), "", "grpc health response is not valid protobuf"},
+	}
+	for _, tt := range tbl {
+		status, err := grpcHealthStatus(tt.frame)
+		if tt.err != "" {
+			assert.EqualError(t, err, tt.err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, tt.status, status)
+	}
+	assert.Equal(t, []byte{0x0a, 3, 'a', 'p', 'i'}, grpcHealthRequest("api"))
+}
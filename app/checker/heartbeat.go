@@ -0,0 +1,66 @@
+package checker
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// HeartbeatCheck describes the push monitor, the job pings its token url at least every Period
+type HeartbeatCheck struct {
+	Token  string
+	Period time.Duration
+	Grace  time.Duration // extra time after Period before the missed heartbeat fails
+}
+
+// heartbeats keeps the time of the last ping of every token
+type heartbeats struct {
+	sync.Mutex
+	last map[string]time.Time
+}
+
+// Beat records the ping of the heartbeat target with the token and returns its name, false if token is unknown
+func (s *Scheduler) Beat(token string, at time.Time) (string, bool) {
+	for _, t := range s.Targets {
+		if t.Type == TypeHeartbeat && t.Heartbeat.Token != "" &&
+			subtle.ConstantTimeCompare([]byte(t.Heartbeat.Token), []byte(token)) == 1 {
+			s.beats.set(t.Heartbeat.Token, at)
+			return t.Name, true
+		}
+	}
+	return "", false
+}
+
+func (h *heartbeats) set(token string, at time.Time) {
+	h.Lock()
+	defer h.Unlock()
+	if h.last == nil {
+		h.last = map[string]time.Time{}
+	}
+	h.last[token] = at
+}
+
+// get returns the last ping, the first call sets it to now, so the deadline is counted since the start
+func (h *heartbeats) get(token string, now time.Time) time.Time {
+	h.Lock()
+	defer h.Unlock()
+	if h.last == nil {
+		h.last = map[string]time.Time{}
+	}
+	if _, ok := h.last[token]; !ok {
+		h.last[token] = now
+	}
+	return h.last[token]
+}
+
+// probeHeartbeat fails when no ping arrived within period and grace
+func (s *Scheduler) probeHeartbeat(t Target) Result {
+	r := Result{Time: time.Now()}
+	last := s.beats.get(t.Heartbeat.Token, r.Time)
+	if since := r.Time.Sub(last); since > t.Heartbeat.Period+t.Heartbeat.Grace {
+		r.Err = fmt.Errorf("no heartbeat for %s since %s, expected every %s with grace %s",
+			since.Round(time.Second), last.Format("2006-01-02 15:04:05 MST"), t.Heartbeat.Period, t.Heartbeat.Grace)
+	}
+	return r
+}
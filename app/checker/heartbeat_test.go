@@ -0,0 +1,34 @@
+package checker
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestHeartbeat(t *testing.T) {
+	target := Target{Name: "backup", Type: TypeHeartbeat, Heartbeat: HeartbeatCheck{Token: "secret", Period: time.Hour, Grace: 10 * time.Minute}}
+	s := &Scheduler{Targets: []Target{target}}
+
+	r := s.probe(context.Background(), target)
+	assert.True(t, r.OK(), "the deadline is counted since the first check")
+
+	name, ok := s.Beat("secret", time.Now().Add(-2*time.Hour))
+	assert.True(t, ok)
+	assert.Equal(t, "backup", name)
+	r = s.probe(context.Background(), target)
+	assert.False(t, r.OK())
+	assert.Contains(t, r.Reason(), "no heartbeat for 2h0m0s since")
+	assert.Contains(t, r.Reason(), "expected every 1h0m0s with grace 10m0s")
+
+	_, ok = s.Beat("secret", time.Now().Add(-65*time.Minute))
+	assert.True(t, ok)
+	r = s.probe(context.Background(), target)
+	assert.True(t, r.OK(), "the heartbeat is in grace time")
+
+	_, ok = s.Beat("other", time.Now())
+	assert.False(t, ok)
+	_, ok = s.Beat("", time.Now())
+	assert.False(t, ok)
+}
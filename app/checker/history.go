@@ -0,0 +1,78 @@
+package checker
+
+import "time"
+
+// limits of the kept history
+const (
+	HistoryDays  = 90
+	MaxIncidents = 50
+)
+
+// Day is the count of probes of the target in the UTC day
+type Day struct {
+	Date   time.Time // UTC midnight
+	Checks int
+	Failed int
+}
+
+// Uptime returns the percent of successful probes in the day
+func (d Day) Uptime() float64 {
+	if d.Checks == 0 {
+		return 100
+	}
+	return float64(d.Checks-d.Failed) * 100 / float64(d.Checks)
+}
+
+// Incident is the period when the target was alerted as down, Resolved is zero for the ongoing incident
+type Incident struct {
+	Target   string
+	Started  time.Time
+	Resolved time.Time
+	Reason   string
+}
+
+// record adds the probe into the day of its time and keeps at most HistoryDays days
+func (s *Status) record(r Result) {
+	date := r.Time.UTC().Truncate(24 * time.Hour)
+	if n := len(s.Days); n == 0 || s.Days[n-1].Date.Before(date) {
+		s.Days = append(s.Days, Day{Date: date})
+	}
+	day := &s.Days[len(s.Days)-1]
+	day.Checks++
+	if !r.OK() {
+		day.Failed++
+	}
+	if len(s.Days) > HistoryDays {
+		s.Days = append([]Day(nil), s.Days[len(s.Days)-HistoryDays:]...)
+	}
+}
+
+// transition opens the incident when the target goes down and resolves it on recovery.
+// It returns the changed incident, the open incident restored from history is continued or resolved by the first probe.
+func (s *Status) transition(prev string, st *state, r Result) *Incident {
+	var open *Incident
+	if n := len(s.Incidents); n > 0 && s.Incidents[n-1].Resolved.IsZero() {
+		open = &s.Incidents[n-1]
+	}
+	switch {
+	case s.State == StatusDown && prev != StatusDown && open == nil:
+		s.Incidents = append(s.Incidents, Incident{Target: s.Name, Started: st.downSince, Reason: r.Reason()})
+		if len(s.Incidents) > MaxIncidents {
+			s.Incidents = append([]Incident(nil), s.Incidents[len(s.Incidents)-MaxIncidents:]...)
+		}
+		i := s.Incidents[len(s.Incidents)-1]
+		return &i
+	case s.State == StatusUp && open != nil:
+		open.Resolved = r.Time
+		i := *open
+		return &i
+	}
+	return nil
+}
+
+// History persists probe results and incidents, so the days and incidents of Status survive restarts
+type History interface {
+	Load(target string) ([]Day, []Incident, error)
+	Record(target string, r Result) error
+	Incident(i Incident) error
+}
@@ -0,0 +1,99 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestStatusRecord(t *testing.T) {
+	day := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := &Status{}
+	s.record(Result{Time: day})
+	s.record(Result{Time: day.Add(time.Hour), Err: errors.New("down")})
+	s.record(Result{Time: day.Add(24 * time.Hour)})
+	assert.Equal(t, []Day{
+		{Date: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Checks: 2, Failed: 1},
+		{Date: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), Checks: 1},
+	}, s.Days)
+	assert.Equal(t, float64(50), s.Days[0].Uptime())
+
+	for i := 0; i < HistoryDays+5; i++ {
+		s.record(Result{Time: day.AddDate(0, 0, i+2)})
+	}
+	assert.Len(t, s.Days, HistoryDays)
+	assert.Equal(t, day.AddDate(0, 0, HistoryDays+6).Truncate(24*time.Hour), s.Days[HistoryDays-1].Date)
+}
+
+func TestStatusIncidents(t *testing.T) {
+	target := Target{Name: "a", MaxAlerts: 1}
+	s := &Scheduler{}
+	st := &state{}
+	s.start(target)
+
+	s.check(context.Background(), target, st, failed)
+	status, _ := s.Status("a")
+	assert.Empty(t, status.Incidents, "failing target has no incident until alert")
+
+	s.check(context.Background(), target, st, failed)
+	s.check(context.Background(), target, st, failed)
+	status, _ = s.Status("a")
+	assert.Len(t, status.Incidents, 1)
+	assert.Equal(t, Incident{Target: "a", Started: failed.Time, Reason: "bad status code 500"}, status.Incidents[0])
+
+	recovered := Result{StatusCode: 200, Time: time.Now()}
+	s.check(context.Background(), target, st, recovered)
+	status, _ = s.Status("a")
+	assert.Equal(t, recovered.Time, status.Incidents[0].Resolved)
+}
+
+type memHistory struct {
+	days      []Day
+	incidents []Incident
+	probes    int
+}
+
+func (m *memHistory) Load(string) ([]Day, []Incident, error) { return m.days, m.incidents, nil }
+
+func (m *memHistory) Record(string, Result) error {
+	m.probes++
+	return nil
+}
+
+func (m *memHistory) Incident(i Incident) error {
+	m.incidents = append(m.incidents, i)
+	return nil
+}
+
+func TestSchedulerHistory(t *testing.T) {
+	started := time.Now().Add(-time.Hour)
+	history := &memHistory{
+		days:      []Day{{Date: time.Now().UTC().Truncate(24 * time.Hour), Checks: 10, Failed: 10}},
+		incidents: []Incident{{Target: "a", Started: started, Reason: "timeout"}},
+	}
+	target := Target{Name: "a", MaxAlerts: 1}
+	s := &Scheduler{History: history}
+	st := &state{}
+	s.start(target)
+
+	status, _ := s.Status("a")
+	assert.Equal(t, history.days, status.Days)
+	assert.Len(t, status.Incidents, 1)
+
+	s.check(context.Background(), target, st, failed)
+	s.check(context.Background(), target, st, failed)
+	s.check(context.Background(), target, st, failed)
+	status, _ = s.Status("a")
+	assert.Len(t, status.Incidents, 1, "the restored open incident is continued")
+	assert.Equal(t, 3, history.probes)
+
+	up := Result{StatusCode: 200, Time: time.Now()}
+	s.check(context.Background(), target, st, up)
+	status, _ = s.Status("a")
+	assert.Equal(t, 14, status.Days[0].Checks)
+	assert.Equal(t, up.Time, status.Incidents[0].Resolved)
+	assert.Equal(t, Incident{Target: "a", Started: started, Resolved: up.Time, Reason: "timeout"}, history.incidents[1],
+		"the resolved incident is persisted")
+}
@@ -0,0 +1,200 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout is the deadline of a single probe when the target timeout is not set
+const DefaultTimeout = 10 * time.Second
+
+// Request describes how the target is requested, GET without auth by default
+type Request struct {
+	Method      string
+	Headers     map[string]string
+	Body        string
+	Username    string // basic auth is used if set
+	Password    string
+	BearerToken string
+}
+
+// build makes the http request of the target
+func (q Request) build(ctx context.Context, url string) (*http.Request, error) {
+	method := q.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	if q.Body != "" {
+		body = strings.NewReader(q.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range q.Headers {
+		req.Header.Set(k, v)
+	}
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+	if q.Username != "" {
+		req.SetBasicAuth(q.Username, q.Password)
+	}
+	if q.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+q.BearerToken)
+	}
+	return req, nil
+}
+
+// StatusCodes is the list of accepted status code ranges
+type StatusCodes [][2]int
+
+// ParseStatusCodes parses comma separated list of codes and ranges, e.g. 200-299,301
+func ParseStatusCodes(s string) (StatusCodes, error) {
+	var res StatusCodes
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		bounds := strings.SplitN(item, "-", 2)
+		from, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		if err != nil {
+			return nil, fmt.Errorf("status code %q is not valid", item)
+		}
+		to := from
+		if len(bounds) == 2 {
+			if to, err = strconv.Atoi(strings.TrimSpace(bounds[1])); err != nil {
+				return nil, fmt.Errorf("status code range %q is not valid", item)
+			}
+		}
+		if from < 100 || to > 599 || from > to {
+			return nil, fmt.Errorf("status code range %q is out of 100-599", item)
+		}
+		res = append(res, [2]int{from, to})
+	}
+	return res, nil
+}
+
+// Match reports whether the code is accepted, only 200 is accepted by empty list
+func (c StatusCodes) Match(code int) bool {
+	if len(c) == 0 {
+		return code == http.StatusOK
+	}
+	for _, r := range c {
+		if code >= r[0] && code <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// redirects reports whether any 3xx code is accepted, so redirects should not be followed
+func (c StatusCodes) redirects() bool {
+	for _, r := range c {
+		if r[0] < 400 && r[1] >= 300 {
+			return true
+		}
+	}
+	return false
+}
+
+// timeout returns the deadline of a single probe
+func (t Target) timeout() time.Duration {
+	if t.Timeout > 0 {
+		return t.Timeout
+	}
+	return DefaultTimeout
+}
+
+// probe checks the target by its type within the target timeout and returns the result of the probe
+func (s *Scheduler) probe(ctx context.Context, t Target) Result {
+	switch t.Type {
+	case TypeTCP:
+		return probeTCP(ctx, t)
+	case TypeDNS:
+		return s.probeDNS(ctx, t)
+	case TypeGRPC:
+		return s.probeGRPC(ctx, t)
+	case TypeWebSocket:
+		return s.probeWebSocket(ctx, t)
+	case TypeHeartbeat:
+		return s.probeHeartbeat(t)
+	default:
+		return s.probeHTTP(ctx, t)
+	}
+}
+
+// probeHTTP requests the target url
+func (s *Scheduler) probeHTTP(ctx context.Context, t Target) Result {
+	r := Result{Time: time.Now()}
+	ctx, cancel := context.WithTimeout(ctx, t.timeout())
+	defer cancel()
+	tr := newTracer(r.Time)
+	req, err := t.Request.build(tr.context(ctx), t.URL)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	client := t.client
+	if client == nil {
+		client = s.Client
+	}
+	if t.Status.redirects() {
+		noRedirect := *client
+		noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+		client = &noRedirect
+	}
+	response, err := client.Do(req)
+	r.Timings = tr.result()
+	r.Latency = r.Timings.Total
+	if err != nil {
+		log.Printf("[DEBUG] target %s get error: %v", t.Name, err)
+		r.Err = t.probeError(err)
+		r.Cert = certFromError(err)
+		return r
+	}
+	defer func() {
+		// the drained body lets the transport reuse the keep-alive connection for the next probe
+		_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, t.Body.Limit()))
+		response.Body.Close()
+	}()
+	r.Cert = inspectCert(response.TLS, t.TLS.ExpiryDays, r.Time)
+	log.Printf("[DEBUG] target %s get response: %+v", t.Name, response)
+	r.StatusCode = response.StatusCode
+	if !t.Status.Match(response.StatusCode) {
+		r.Err = fmt.Errorf("bad status code %d", response.StatusCode)
+		return r
+	}
+	if t.Body.Empty() && len(t.JSON) == 0 {
+		return r
+	}
+	body, err := io.ReadAll(io.LimitReader(response.Body, t.Body.Limit()))
+	r.Timings = tr.result()
+	r.Latency = r.Timings.Total
+	if err != nil {
+		r.Err = t.probeError(fmt.Errorf("can't read body: %w", err))
+		return r
+	}
+	if r.Err = t.Body.Check(body); r.Err == nil {
+		r.Err = checkJSON(body, t.JSON)
+	}
+	return r
+}
+
+// probeError replaces the deadline error by the human readable timeout reason
+func (t Target) probeError(err error) error {
+	var timeout interface{ Timeout() bool }
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &timeout) && timeout.Timeout()) {
+		return fmt.Errorf("timeout after %s", t.timeout())
+	}
+	return err
+}
@@ -0,0 +1,115 @@
+package checker
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseStatusCodes(t *testing.T) {
+	codes, err := ParseStatusCodes("200-299, 301")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusCodes{{200, 299}, {301, 301}}, codes)
+	assert.True(t, codes.Match(204))
+	assert.True(t, codes.Match(301))
+	assert.False(t, codes.Match(302))
+	assert.True(t, codes.redirects())
+
+	assert.True(t, StatusCodes{}.Match(200))
+	assert.False(t, StatusCodes{}.Match(204))
+
+	for _, s := range []string{"abc", "200-x", "299-200", "99", "200-600"} {
+		_, err = ParseStatusCodes(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestProbeRequest(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.URL.Path == "/old":
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		case r.Method != "POST" || string(body) != `{"ping":1}` || r.Header.Get("X-Probe") != "1":
+			w.WriteHeader(http.StatusBadRequest)
+		case r.Header.Get("Authorization") != "Bearer token":
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer ts.Close()
+
+	s := &Scheduler{Client: ts.Client()}
+	target := Target{
+		Name: "api",
+		URL:  ts.URL,
+		Request: Request{
+			Method:      "POST",
+			Headers:     map[string]string{"X-Probe": "1"},
+			Body:        `{"ping":1}`,
+			BearerToken: "token",
+		},
+	}
+	r := s.probe(context.Background(), target)
+	assert.Equal(t, "bad status code 204", r.Reason(), "only 200 is accepted by default")
+
+	target.Status = StatusCodes{{200, 299}}
+	assert.True(t, s.probe(context.Background(), target).OK())
+
+	target.Request.BearerToken = ""
+	assert.Equal(t, "bad status code 401", s.probe(context.Background(), target).Reason())
+
+	r = s.probe(context.Background(), Target{Name: "old", URL: ts.URL + "/old", Status: StatusCodes{{301, 301}}})
+	assert.True(t, r.OK())
+	assert.Equal(t, http.StatusMovedPermanently, r.StatusCode)
+}
+
+func TestProbeTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer ts.Close()
+
+	s := &Scheduler{Client: ts.Client()}
+	st := time.Now()
+	r := s.probe(context.Background(), Target{Name: "slow", URL: ts.URL, Timeout: 50 * time.Millisecond})
+	assert.Less(t, time.Since(st), time.Second)
+	assert.Equal(t, "timeout after 50ms", r.Reason())
+}
+
+func TestProbeReusesConnection(t *testing.T) {
+	var conns int32
+	var mu sync.Mutex
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("<p>page</p>", 80000)))
+	}))
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			conns++
+			mu.Unlock()
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	s := &Scheduler{Client: ts.Client()}
+	target := Target{Name: "web", URL: ts.URL}
+	for i := 0; i < 3; i++ {
+		assert.True(t, s.probe(context.Background(), target).OK())
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, int32(1), conns, "unread body is drained to keep the connection alive")
+}
@@ -0,0 +1,227 @@
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// JSON assertion comparators
+const (
+	OpEqual        = "=="
+	OpNotEqual     = "!="
+	OpGreater      = ">"
+	OpGreaterEqual = ">="
+	OpLess         = "<"
+	OpLessEqual    = "<="
+	OpContains     = "contains"
+	OpExists       = "exists"
+)
+
+// JSONAssertion checks the value found by Path in the JSON body against Value.
+// Path is JSONPath-like expression supporting only $, .key, ["key"] and [index], e.g. $.checks.db.status
+type JSONAssertion struct {
+	Path  string
+	Op    string // one of Op* comparators, OpEqual if empty
+	Value interface{}
+}
+
+// String returns the assertion as expression, e.g. $.status == "UP"
+func (j JSONAssertion) String() string {
+	if j.op() == OpExists {
+		return fmt.Sprintf("%s exists", j.Path)
+	}
+	v, _ := json.Marshal(j.Value)
+	return fmt.Sprintf("%s %s %s", j.Path, j.op(), v)
+}
+
+// Validate checks the path syntax and the comparator
+func (j JSONAssertion) Validate() error {
+	if _, err := parsePath(j.Path); err != nil {
+		return err
+	}
+	switch j.op() {
+	case OpEqual, OpNotEqual, OpContains, OpExists:
+	case OpGreater, OpGreaterEqual, OpLess, OpLessEqual:
+		if _, ok := number(j.Value); !ok {
+			return fmt.Errorf("comparator %s requires number value", j.op())
+		}
+	default:
+		return fmt.Errorf("comparator %q is not supported", j.Op)
+	}
+	return nil
+}
+
+// Check evaluates the assertion for the decoded JSON document
+func (j JSONAssertion) Check(doc interface{}) error {
+	keys, err := parsePath(j.Path)
+	if err != nil {
+		return err
+	}
+	actual, found := lookup(doc, keys)
+	if j.op() == OpExists {
+		if !found {
+			return fmt.Errorf("json assertion %s failed: not found", j)
+		}
+		return nil
+	}
+	if !found {
+		return fmt.Errorf("json assertion %s failed: %s not found", j, j.Path)
+	}
+	if !compare(actual, j.op(), j.Value) {
+		got, _ := json.Marshal(actual)
+		return fmt.Errorf("json assertion %s failed: got %s", j, got)
+	}
+	return nil
+}
+
+func (j JSONAssertion) op() string {
+	if j.Op == "" {
+		return OpEqual
+	}
+	return j.Op
+}
+
+// checkJSON decodes the body and checks all assertions
+func checkJSON(body []byte, assertions []JSONAssertion) error {
+	if len(assertions) == 0 {
+		return nil
+	}
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("body is not valid json: %w", err)
+	}
+	for _, a := range assertions {
+		if err := a.Check(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parsePath splits the path into keys, string for object key and int for array index
+func parsePath(path string) ([]interface{}, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("json path %q should start with $", path)
+	}
+	var keys []interface{}
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("json path %q has empty key", path)
+			}
+			keys = append(keys, rest[1:end+1])
+			rest = rest[end+1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("json path %q has unclosed bracket", path)
+			}
+			inner := rest[1:end]
+			if unquoted, err := strconv.Unquote(inner); err == nil {
+				keys = append(keys, unquoted)
+			} else if idx, err := strconv.Atoi(inner); err == nil && idx >= 0 {
+				keys = append(keys, idx)
+			} else {
+				return nil, fmt.Errorf("json path %q has invalid index %s", path, inner)
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("json path %q is not valid at %q", path, rest)
+		}
+	}
+	return keys, nil
+}
+
+func lookup(doc interface{}, keys []interface{}) (interface{}, bool) {
+	cur := doc
+	for _, k := range keys {
+		switch key := k.(type) {
+		case string:
+			obj, ok := cur.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if cur, ok = obj[key]; !ok {
+				return nil, false
+			}
+		case int:
+			arr, ok := cur.([]interface{})
+			if !ok || key >= len(arr) {
+				return nil, false
+			}
+			cur = arr[key]
+		}
+	}
+	return cur, true
+}
+
+func compare(actual interface{}, op string, expected interface{}) bool {
+	switch op {
+	case OpEqual:
+		return equal(actual, expected)
+	case OpNotEqual:
+		return !equal(actual, expected)
+	case OpContains:
+		switch v := actual.(type) {
+		case string:
+			return strings.Contains(v, fmt.Sprint(expected))
+		case []interface{}:
+			for _, item := range v {
+				if equal(item, expected) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	a, aok := number(actual)
+	e, eok := number(expected)
+	if !aok || !eok {
+		return false
+	}
+	switch op {
+	case OpGreater:
+		return a > e
+	case OpGreaterEqual:
+		return a >= e
+	case OpLess:
+		return a < e
+	case OpLessEqual:
+		return a <= e
+	}
+	return false
+}
+
+func equal(actual, expected interface{}) bool {
+	if a, ok := number(actual); ok {
+		e, ok := number(expected)
+		return ok && a == e
+	}
+	return reflect.DeepEqual(actual, expected)
+}
+
+// number converts json and yaml numeric values to float64
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,55 @@
+package checker
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestJSONAssertionCheck(t *testing.T) {
+	var doc interface{}
+	err := json.Unmarshal([]byte(`{"status":"UP","checks":{"db":{"status":"DOWN","latency":120}},
+		"nodes":[{"name":"a"},{"name":"b"}],"tags":["prod","eu"],"my.key":true}`), &doc)
+	assert.NoError(t, err)
+
+	tbl := []struct {
+		assertion JSONAssertion
+		err       string
+	}{
+		{JSONAssertion{Path: "$.status", Value: "UP"}, ""},
+		{JSONAssertion{Path: "$.checks.db.status", Op: "==", Value: "UP"}, `json assertion $.checks.db.status == "UP" failed: got "DOWN"`},
+		{JSONAssertion{Path: "$.checks.db.latency", Op: "<", Value: 500}, ""},
+		{JSONAssertion{Path: "$.checks.db.latency", Op: ">=", Value: 500}, `json assertion $.checks.db.latency >= 500 failed: got 120`},
+		{JSONAssertion{Path: "$.checks.db.latency", Op: "==", Value: 120}, ""},
+		{JSONAssertion{Path: "$.nodes[1].name", Op: "!=", Value: "a"}, ""},
+		{JSONAssertion{Path: "$.nodes[5].name", Value: "a"}, `json assertion $.nodes[5].name == "a" failed: $.nodes[5].name not found`},
+		{JSONAssertion{Path: "$.tags", Op: "contains", Value: "prod"}, ""},
+		{JSONAssertion{Path: `$["my.key"]`, Value: true}, ""},
+		{JSONAssertion{Path: "$.checks.cache", Op: "exists"}, "json assertion $.checks.cache exists failed: not found"},
+	}
+	for i, tt := range tbl {
+		err := tt.assertion.Check(doc)
+		if tt.err == "" {
+			assert.NoError(t, err, "case #%d", i)
+			continue
+		}
+		assert.EqualError(t, err, tt.err, "case #%d", i)
+	}
+}
+
+func TestJSONAssertionValidate(t *testing.T) {
+	assert.NoError(t, JSONAssertion{Path: "$.a[0][\"b\"].c", Op: "contains", Value: "x"}.Validate())
+	assert.Error(t, JSONAssertion{Path: "a.b"}.Validate())
+	assert.Error(t, JSONAssertion{Path: "$.a[x]"}.Validate())
+	assert.Error(t, JSONAssertion{Path: "$..a"}.Validate())
+	assert.Error(t, JSONAssertion{Path: "$.a", Op: "~="}.Validate())
+	assert.Error(t, JSONAssertion{Path: "$.a", Op: ">", Value: "10"}.Validate())
+}
+
+func TestCheckJSON(t *testing.T) {
+	assertions := []JSONAssertion{{Path: "$.status", Value: "UP"}}
+	assert.NoError(t, checkJSON([]byte(`{"status":"UP"}`), assertions))
+	assert.EqualError(t, checkJSON([]byte(`<html>`), assertions),
+		"body is not valid json: invalid character '<' looking for beginning of value")
+	assert.NoError(t, checkJSON([]byte(`<html>`), nil))
+}
@@ -0,0 +1,103 @@
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings of the probe phases, DNS, Connect and TLS are zero for the reused connection
+type Timings struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	TTFB    time.Duration // time to the first response byte since the probe start
+	Total   time.Duration
+}
+
+// String returns the timings rounded to milliseconds
+func (t Timings) String() string {
+	ms := func(d time.Duration) time.Duration { return d.Round(time.Millisecond) }
+	return fmt.Sprintf("dns %s, connect %s, tls %s, ttfb %s, total %s",
+		ms(t.DNS), ms(t.Connect), ms(t.TLS), ms(t.TTFB), ms(t.Total))
+}
+
+// LatencyThreshold defines when the slow target is reported, thresholds are compared with the total time
+type LatencyThreshold struct {
+	Warning  time.Duration // the slow probe is logged, disabled if 0
+	Critical time.Duration // the slow probe is counted, disabled if 0
+	Count    int           // consecutive probes over Critical to fail the target, 1 if 0
+}
+
+// check counts the consecutive critical probes and returns the error once Count is reached
+func (l LatencyThreshold) check(st *state, t Timings) error {
+	if l.Critical <= 0 || t.Total <= l.Critical {
+		st.slow = 0
+		return nil
+	}
+	st.slow++
+	count := l.Count
+	if count <= 0 {
+		count = 1
+	}
+	if st.slow < count {
+		return nil
+	}
+	return fmt.Errorf("latency %s exceeds critical %s in %d consecutive probe(s) (%s)",
+		t.Total.Round(time.Millisecond), l.Critical, st.slow, t)
+}
+
+// tracer measures the timings of a single request
+type tracer struct {
+	sync.Mutex
+	start                         time.Time
+	dnsStart, connStart, tlsStart time.Time
+	timings                       Timings
+}
+
+func newTracer(start time.Time) *tracer {
+	return &tracer{start: start}
+}
+
+// context returns ctx with the client trace collecting the timings
+func (tr *tracer) context(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { tr.mark(&tr.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { tr.since(&tr.timings.DNS, &tr.dnsStart) },
+		ConnectStart: func(string, string) {
+			tr.mark(&tr.connStart)
+		},
+		ConnectDone: func(string, string, error) {
+			tr.since(&tr.timings.Connect, &tr.connStart)
+		},
+		TLSHandshakeStart: func() { tr.mark(&tr.tlsStart) },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			tr.since(&tr.timings.TLS, &tr.tlsStart)
+		},
+		GotFirstResponseByte: func() { tr.since(&tr.timings.TTFB, &tr.start) },
+	})
+}
+
+func (tr *tracer) mark(t *time.Time) {
+	tr.Lock()
+	defer tr.Unlock()
+	*t = time.Now()
+}
+
+func (tr *tracer) since(d *time.Duration, from *time.Time) {
+	tr.Lock()
+	defer tr.Unlock()
+	*d = time.Since(*from)
+}
+
+// result returns the timings with the total time since the start
+func (tr *tracer) result() Timings {
+	tr.Lock()
+	defer tr.Unlock()
+	res := tr.timings
+	res.Total = time.Since(tr.start)
+	return res
+}
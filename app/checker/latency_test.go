@@ -0,0 +1,61 @@
+package checker
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/theshamuel/hhchecker/app/provider"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLatencyThresholdCheck(t *testing.T) {
+	l := LatencyThreshold{Critical: time.Second, Count: 2}
+	st := &state{}
+	slow := Timings{TTFB: 2 * time.Second, Total: 2 * time.Second}
+
+	assert.NoError(t, l.check(st, slow))
+	err := l.check(st, slow)
+	assert.EqualError(t, err, "latency 2s exceeds critical 1s in 2 consecutive probe(s) "+
+		"(dns 0s, connect 0s, tls 0s, ttfb 2s, total 2s)")
+
+	assert.NoError(t, l.check(st, Timings{Total: time.Millisecond}))
+	assert.Equal(t, 0, st.slow)
+	assert.NoError(t, LatencyThreshold{}.check(st, slow), "disabled threshold")
+}
+
+func TestCheckAlertsSlowTarget(t *testing.T) {
+	mock := &mockProvider{}
+	s := &Scheduler{Providers: []provider.Interface{mock}}
+	target := Target{Name: "a", MaxAlerts: 3, Latency: LatencyThreshold{Critical: time.Second, Count: 2}}
+	st := &state{}
+	slow := Result{StatusCode: 200, Time: time.Now(), Timings: Timings{Total: 2 * time.Second}}
+
+	s.check(context.Background(), target, st, slow)
+	assert.Empty(t, mock.notifications())
+	s.check(context.Background(), target, st, slow)
+	sent := mock.notifications()
+	assert.Len(t, sent, 1, "alert without waiting for max alerts")
+	assert.True(t, strings.HasPrefix(sent[0].Reason, "latency 2s exceeds critical 1s"))
+
+	s.check(context.Background(), target, st, succeeded)
+	assert.Len(t, mock.notifications(), 2)
+	assert.Equal(t, provider.StateRecovered, mock.notifications()[1].State)
+}
+
+func TestProbeTimings(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	s := &Scheduler{Client: &http.Client{}}
+	r := s.probe(context.Background(), Target{Name: "a", URL: ts.URL})
+	assert.True(t, r.OK())
+	assert.GreaterOrEqual(t, r.Timings.TTFB, 20*time.Millisecond)
+	assert.Greater(t, r.Timings.Connect, time.Duration(0))
+	assert.GreaterOrEqual(t, r.Timings.Total, r.Timings.TTFB)
+	assert.Equal(t, r.Timings.Total, r.Latency)
+}
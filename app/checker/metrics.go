@@ -0,0 +1,86 @@
+package checker
+
+import (
+	"github.com/theshamuel/hhchecker/app/provider"
+	"sync"
+	"time"
+)
+
+// DurationBuckets are the upper bounds in seconds of the probe duration histogram
+var DurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Histogram is the cumulative distribution of durations by DurationBuckets
+type Histogram struct {
+	Buckets []uint64 // the count of durations less or equal to the bound of the same index
+	Count   uint64
+	Sum     float64 // in seconds
+}
+
+func (h *Histogram) observe(d time.Duration) {
+	if h.Buckets == nil {
+		h.Buckets = make([]uint64, len(DurationBuckets))
+	}
+	v := d.Seconds()
+	for i, bound := range DurationBuckets {
+		if v <= bound {
+			h.Buckets[i]++
+		}
+	}
+	h.Count++
+	h.Sum += v
+}
+
+// observeTimings adds the probe phases to histograms, the phases skipped by the reused connection are not observed
+func observeTimings(durations map[string]*Histogram, t Timings) {
+	phases := []struct {
+		name string
+		d    time.Duration
+	}{{"dns", t.DNS}, {"connect", t.Connect}, {"tls", t.TLS}, {"ttfb", t.TTFB}, {"total", t.Total}}
+	for _, p := range phases {
+		if p.d <= 0 && p.name != "total" {
+			continue
+		}
+		if durations[p.name] == nil {
+			durations[p.name] = &Histogram{}
+		}
+		durations[p.name].observe(p.d)
+	}
+}
+
+// NotifyCount is the count of sent and failed alerts of the provider
+type NotifyCount struct {
+	Sent   uint64
+	Failed uint64
+}
+
+type notifyCounts struct {
+	sync.Mutex
+	byID map[provider.ID]*NotifyCount
+}
+
+func (n *notifyCounts) add(id provider.ID, err error) {
+	n.Lock()
+	defer n.Unlock()
+	if n.byID == nil {
+		n.byID = map[provider.ID]*NotifyCount{}
+	}
+	if n.byID[id] == nil {
+		n.byID[id] = &NotifyCount{}
+	}
+	if err != nil {
+		n.byID[id].Failed++
+		return
+	}
+	n.byID[id].Sent++
+}
+
+// Notifications returns the count of sent and failed alerts of every provider since the start
+func (s *Scheduler) Notifications() map[provider.ID]NotifyCount {
+	s.notifyCounts.Lock()
+	defer s.notifyCounts.Unlock()
+	res := make(map[provider.ID]NotifyCount, len(s.notifyCounts.byID))
+	for id, c := range s.notifyCounts.byID {
+		res[id] = *c
+	}
+	return res
+}
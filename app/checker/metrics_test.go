@@ -0,0 +1,63 @@
+package checker
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/theshamuel/hhchecker/app/provider"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestHistogram(t *testing.T) {
+	h := &Histogram{}
+	h.observe(20 * time.Millisecond)
+	h.observe(3 * time.Second)
+	h.observe(time.Minute)
+	assert.Equal(t, []uint64{0, 0, 1, 1, 1, 1, 1, 1, 1, 2, 2}, h.Buckets)
+	assert.Equal(t, uint64(3), h.Count)
+	assert.InDelta(t, 63.02, h.Sum, 0.0001)
+
+	durations := map[string]*Histogram{}
+	observeTimings(durations, Timings{TTFB: 10 * time.Millisecond, Total: 20 * time.Millisecond})
+	assert.Len(t, durations, 2, "skipped phases of reused connection are not observed")
+	assert.Equal(t, uint64(1), durations["ttfb"].Count)
+	assert.Equal(t, uint64(1), durations["total"].Count)
+}
+
+func TestNotifications(t *testing.T) {
+	s := &Scheduler{
+		Providers:     []provider.Interface{hungProvider{}, &mockProvider{}},
+		NotifyTimeout: 10 * time.Millisecond,
+	}
+	s.notify(context.Background(), provider.Alert{Target: "a"})
+	s.notify(context.Background(), provider.Alert{Target: "a"})
+	assert.Equal(t, map[provider.ID]NotifyCount{"hung": {Failed: 2}, "mock": {Sent: 2}}, s.Notifications())
+}
+
+func TestNotificationsFailedByStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+	api, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		r = r.Clone(r.Context())
+		r.URL.Scheme, r.URL.Host = api.Scheme, api.Host // the provider api is replaced by the test server
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	s := &Scheduler{Providers: []provider.Interface{
+		&provider.Telegram{BotAPIKey: "key", ChannelID: "1", Provider: provider.Provider{ID: provider.PIDTelegram, Client: client}},
+		&provider.Mailgun{Domain: "example.com", APIKey: "api:key", Values: map[string]string{"text": ""},
+			Provider: provider.Provider{ID: provider.PIDMailgun, Client: client}},
+	}}
+	s.notify(context.Background(), provider.Alert{Target: "a", State: provider.StateDown})
+	assert.Equal(t, map[provider.ID]NotifyCount{"telegram": {Failed: 1}, "mailgun": {Failed: 1}}, s.Notifications())
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
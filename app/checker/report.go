@@ -0,0 +1,183 @@
+package checker
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// formats of the report
+const (
+	ReportText = "text"
+	ReportCSV  = "csv"
+	ReportJSON = "json"
+)
+
+// DefaultReportPeriod is the period of the report without start
+const DefaultReportPeriod = 30 * 24 * time.Hour
+
+// Report is the availability of the target over the period. Probes are counted by whole UTC days.
+type Report struct {
+	Target        string
+	Checks        int
+	Failed        int
+	Incidents     int           // incidents overlapping the period, including the ongoing one
+	MTTR          time.Duration // the mean time from start to resolve of the resolved incidents
+	LongestOutage time.Duration // the longest incident within the period
+}
+
+// Uptime returns the percent of successful probes in the period, 100 if there was no probe
+func (r Report) Uptime() float64 {
+	if r.Checks == 0 {
+		return 100
+	}
+	return float64(r.Checks-r.Failed) * 100 / float64(r.Checks)
+}
+
+// NewReport counts the days and incidents of the target overlapping the period from-to
+func NewReport(target string, days []Day, incidents []Incident, from, to time.Time) Report {
+	r := Report{Target: target}
+	for _, d := range days {
+		if d.Date.Add(24*time.Hour).After(from) && d.Date.Before(to) {
+			r.Checks += d.Checks
+			r.Failed += d.Failed
+		}
+	}
+	var repair time.Duration
+	var resolved int
+	for _, i := range incidents {
+		end := i.Resolved
+		if end.IsZero() {
+			end = to
+		}
+		if !i.Started.Before(to) || !end.After(from) {
+			continue
+		}
+		r.Incidents++
+		if !i.Resolved.IsZero() {
+			repair += i.Resolved.Sub(i.Started)
+			resolved++
+		}
+		start := i.Started
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if outage := end.Sub(start); outage > r.LongestOutage {
+			r.LongestOutage = outage
+		}
+	}
+	if resolved > 0 {
+		r.MTTR = repair / time.Duration(resolved)
+	}
+	return r
+}
+
+// Reports returns the report of every target over the period from the history, the status days and incidents
+// are used if there is no history
+func (s *Scheduler) Reports(from, to time.Time) []Report {
+	res := make([]Report, 0, len(s.Targets))
+	for _, t := range s.Targets {
+		st, ok := s.Status(t.Name)
+		days, incidents := st.Days, st.Incidents
+		if s.History != nil {
+			var err error
+			if days, incidents, err = s.History.Load(t.Name); err != nil {
+				log.Printf("[WARN] can't load history of target %s: %v", t.Name, err)
+				continue
+			}
+		} else if !ok {
+			continue
+		}
+		res = append(res, NewReport(t.Name, days, incidents, from, to))
+	}
+	return res
+}
+
+// ParsePeriod parses the start and the end of the report as date or RFC3339 time. The end is now if empty,
+// the start is DefaultReportPeriod before the end if empty.
+func ParsePeriod(from, to string, now time.Time) (time.Time, time.Time, error) {
+	end, start := now, time.Time{}
+	var err error
+	if to != "" {
+		if end, err = parseTime(to); err != nil {
+			return start, end, fmt.Errorf("period end is not valid: %w", err)
+		}
+	}
+	start = end.Add(-DefaultReportPeriod)
+	if from != "" {
+		if start, err = parseTime(from); err != nil {
+			return start, end, fmt.Errorf("period start is not valid: %w", err)
+		}
+	}
+	if !start.Before(end) {
+		return start, end, fmt.Errorf("period start %s is not before end %s", start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+	return start, end, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+type reportsJSON struct {
+	From    time.Time    `json:"from"`
+	To      time.Time    `json:"to"`
+	Targets []reportJSON `json:"targets"`
+}
+
+type reportJSON struct {
+	Target           string  `json:"target"`
+	Uptime           float64 `json:"uptime_percent"`
+	Checks           int     `json:"checks"`
+	Failed           int     `json:"failed_checks"`
+	Incidents        int     `json:"incidents"`
+	MTTRSec          int64   `json:"mttr_sec"`
+	LongestOutageSec int64   `json:"longest_outage_sec"`
+}
+
+// WriteReports writes the reports of the same period in one of Report* formats
+func WriteReports(w io.Writer, format string, from, to time.Time, reports []Report) error {
+	switch format {
+	case ReportText, "":
+		fmt.Fprintf(w, "Uptime from %s to %s\n\n", from.Format(time.RFC3339), to.Format(time.RFC3339))
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "TARGET\tUPTIME\tCHECKS\tFAILED\tINCIDENTS\tMTTR\tLONGEST OUTAGE")
+		for _, r := range reports {
+			fmt.Fprintf(tw, "%s\t%.3f%%\t%d\t%d\t%d\t%s\t%s\n", r.Target, r.Uptime(), r.Checks, r.Failed, r.Incidents,
+				r.MTTR.Round(time.Second), r.LongestOutage.Round(time.Second))
+		}
+		return tw.Flush()
+	case ReportCSV:
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"target", "from", "to", "uptime_percent", "checks", "failed_checks", "incidents",
+			"mttr_sec", "longest_outage_sec"})
+		for _, r := range reports {
+			_ = cw.Write([]string{r.Target, from.Format(time.RFC3339), to.Format(time.RFC3339),
+				strconv.FormatFloat(r.Uptime(), 'f', 3, 64), strconv.Itoa(r.Checks), strconv.Itoa(r.Failed),
+				strconv.Itoa(r.Incidents), strconv.FormatInt(int64(r.MTTR.Seconds()), 10),
+				strconv.FormatInt(int64(r.LongestOutage.Seconds()), 10)})
+		}
+		cw.Flush()
+		return cw.Error()
+	case ReportJSON:
+		res := reportsJSON{From: from, To: to, Targets: []reportJSON{}}
+		for _, r := range reports {
+			res.Targets = append(res.Targets, reportJSON{Target: r.Target, Uptime: r.Uptime(), Checks: r.Checks,
+				Failed: r.Failed, Incidents: r.Incidents, MTTRSec: int64(r.MTTR.Seconds()),
+				LongestOutageSec: int64(r.LongestOutage.Seconds())})
+		}
+		return json.NewEncoder(w).Encode(res)
+	}
+	return fmt.Errorf("report format %q is not one of text, csv, json", format)
+}
@@ -0,0 +1,83 @@
+package checker
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNewReport(t *testing.T) {
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	days := []Day{
+		{Date: from.AddDate(0, 0, -1), Checks: 100, Failed: 100},
+		{Date: from, Checks: 1000, Failed: 1},
+		{Date: from.AddDate(0, 0, 29), Checks: 1000, Failed: 3},
+		{Date: to, Checks: 100, Failed: 100},
+	}
+	incidents := []Incident{
+		{Started: from.Add(-2 * time.Hour), Resolved: from.Add(-time.Hour)},
+		{Started: from.Add(-time.Hour), Resolved: from.Add(time.Hour)},
+		{Started: from.Add(48 * time.Hour), Resolved: from.Add(48*time.Hour + 10*time.Minute)},
+		{Started: to.Add(-30 * time.Minute)},
+	}
+	r := NewReport("api", days, incidents, from, to)
+	assert.Equal(t, Report{Target: "api", Checks: 2000, Failed: 4, Incidents: 3, MTTR: 65 * time.Minute,
+		LongestOutage: time.Hour}, r)
+	assert.Equal(t, 99.8, r.Uptime())
+
+	r = NewReport("api", nil, nil, from, to)
+	assert.Equal(t, float64(100), r.Uptime(), "no probes")
+	assert.Zero(t, r.MTTR)
+}
+
+func TestParsePeriod(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	from, to, err := ParsePeriod("", "", now)
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(-DefaultReportPeriod), from)
+	assert.Equal(t, now, to)
+
+	from, to, err = ParsePeriod("2026-09-01", "2026-10-01T00:00:00Z", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), from)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), to)
+
+	_, _, err = ParsePeriod("yesterday", "", now)
+	assert.EqualError(t, err, `period start is not valid: parsing time "yesterday" as "2006-01-02T15:04:05Z07:00": cannot parse "yesterday" as "2006"`)
+	_, _, err = ParsePeriod("2026-10-01", "2026-09-01", now)
+	assert.EqualError(t, err, "period start 2026-10-01T00:00:00Z is not before end 2026-09-01T00:00:00Z")
+}
+
+func TestWriteReports(t *testing.T) {
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	reports := []Report{
+		{Target: "api", Checks: 2000, Failed: 4, Incidents: 3, MTTR: 65 * time.Minute, LongestOutage: time.Hour},
+		{Target: "blog"},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteReports(&buf, ReportText, from, to, reports))
+	assert.Equal(t, `Uptime from 2026-09-01T00:00:00Z to 2026-10-01T00:00:00Z
+
+TARGET  UPTIME    CHECKS  FAILED  INCIDENTS  MTTR    LONGEST OUTAGE
+api     99.800%   2000    4       3          1h5m0s  1h0m0s
+blog    100.000%  0       0       0          0s      0s
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, WriteReports(&buf, ReportCSV, from, to, reports))
+	assert.Equal(t, `target,from,to,uptime_percent,checks,failed_checks,incidents,mttr_sec,longest_outage_sec
+api,2026-09-01T00:00:00Z,2026-10-01T00:00:00Z,99.800,2000,4,3,3900,3600
+blog,2026-09-01T00:00:00Z,2026-10-01T00:00:00Z,100.000,0,0,0,0,0
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, WriteReports(&buf, ReportJSON, from, to, reports[1:]))
+	assert.Equal(t, `{"from":"2026-09-01T00:00:00Z","to":"2026-10-01T00:00:00Z","targets":[{"target":"blog",`+
+		`"uptime_percent":100,"checks":0,"failed_checks":0,"incidents":0,"mttr_sec":0,"longest_outage_sec":0}]}`+"\n", buf.String())
+
+	assert.EqualError(t, WriteReports(&buf, "xml", from, to, reports), `report format "xml" is not one of text, csv, json`)
+}
@@ -0,0 +1,330 @@
+package checker
+
+import (
+	"context"
+	"github.com/theshamuel/hhchecker/app/provider"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// kinds of the check
+const (
+	TypeHTTP      = "http"
+	TypeTCP       = "tcp"
+	TypeDNS       = "dns"
+	TypeGRPC      = "grpc"
+	TypeWebSocket = "websocket"
+	TypeHeartbeat = "heartbeat"
+)
+
+// Target describes a single endpoint under health check
+type Target struct {
+	Name      string
+	Type      string // one of Type* kinds, TypeHTTP if empty
+	URL       string // host:port for TypeTCP, the queried name for TypeDNS, host:port for TypeGRPC, ws:// or wss:// for TypeWebSocket, unused by TypeHeartbeat
+	Interval  time.Duration
+	Timeout   time.Duration // the deadline of a single probe, DefaultTimeout if 0
+	MaxAlerts int8
+	Request   Request
+	Status    StatusCodes // accepted status codes, 200 only if empty
+	Body      BodyAssertion
+	JSON      []JSONAssertion
+	Latency   LatencyThreshold
+	TLS       TLS
+	TCP       TCPCheck
+	DNS       DNSCheck
+	GRPC      GRPCCheck
+	WebSocket WebSocketCheck
+	Heartbeat HeartbeatCheck
+	client    *http.Client // the client with target TLS settings, Scheduler.Client if nil
+}
+
+// Scheduler probes all targets concurrently and fans out alerts to the providers
+type Scheduler struct {
+	Targets       []Target
+	Providers     []provider.Interface
+	Client        *http.Client
+	NotifyTimeout time.Duration // the deadline of sending alert by every provider, no deadline if 0
+	History       History       // the history is kept in memory only if nil
+	Digests       []Digest
+	beats         heartbeats
+	statuses      statuses
+	notifyCounts  notifyCounts
+	periods       periods
+	answers       dnsAnswers
+}
+
+// Result of a single probe
+type Result struct {
+	StatusCode int
+	Err        error
+	Latency    time.Duration
+	Timings    Timings
+	Cert       *Cert // the peer certificate of HTTPS target, nil if there was no handshake
+	Time       time.Time
+	change     string // how the dns answer differs from the previous one, sent as the notice
+}
+
+// Reason returns why the probe failed or empty string for the successful probe
+func (r Result) Reason() string {
+	if r.Err != nil {
+		return r.Err.Error()
+	}
+	return ""
+}
+
+// OK reports whether the probe is successful
+func (r Result) OK() bool {
+	return r.Reason() == ""
+}
+
+// state keeps the probe history of a single target
+type state struct {
+	failures    int8
+	consecutive int       // failed probes in a row, unlike failures it is not reset by alert
+	slow        int       // probes in a row over the critical latency
+	down        bool      // alert was sent and the target has not recovered yet
+	downSince   time.Time // time of the first failed probe in the current sequence
+	certKind    string    // the kind of alerted certificate problem, empty if certificate is valid
+	certSince   time.Time // time of the first probe with the certificate problem
+}
+
+// Run starts probing of every target in its own goroutine, sends digests on their schedules
+// and blocks until ctx is done
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	s.periods.reset(len(s.Digests), time.Now())
+	if len(s.Digests) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.digests(ctx)
+		}()
+	}
+	for _, t := range s.Targets {
+		wg.Add(1)
+		go func(t Target) {
+			defer wg.Done()
+			s.watch(ctx, t)
+		}(t)
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) watch(ctx context.Context, t Target) {
+	log.Printf("[INFO] start watching target %s [%s] every %s", t.Name, t.URL, t.Interval)
+	client, err := s.httpClient(t)
+	if err != nil {
+		log.Printf("[ERROR] target %s is not watched: %v", t.Name, err)
+		return
+	}
+	t.client = client
+	if t.Type == TypeHeartbeat {
+		s.beats.get(t.Heartbeat.Token, time.Now())
+	}
+	s.start(t)
+	ticker := time.NewTicker(t.Interval)
+	defer ticker.Stop()
+
+	st := &state{}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r := s.probe(ctx, t)
+			if ctx.Err() != nil {
+				return
+			}
+			s.check(ctx, t, st, r)
+		}
+	}
+}
+
+// start adds the target status with the history
+func (s *Scheduler) start(t Target) {
+	var days []Day
+	var incidents []Incident
+	if s.History != nil {
+		var err error
+		if days, incidents, err = s.History.Load(t.Name); err != nil {
+			log.Printf("[WARN] can't load history of target %s: %v", t.Name, err)
+		}
+	}
+	s.statuses.start(t, time.Now(), days, incidents)
+}
+
+// record updates the target status, counts the probe for digests and persists the probe and the changed incident
+func (s *Scheduler) record(t Target, st *state, r Result) {
+	incident := s.statuses.update(t, st, r)
+	s.periods.observe(t.Name, r)
+	if s.History == nil {
+		return
+	}
+	if err := s.History.Record(t.Name, r); err != nil {
+		log.Printf("[WARN] can't record probe of target %s: %v", t.Name, err)
+	}
+	if incident == nil {
+		return
+	}
+	if err := s.History.Incident(*incident); err != nil {
+		log.Printf("[WARN] can't record incident of target %s: %v", t.Name, err)
+	}
+}
+
+// check updates the target state by the probe result. It sends alert when failures reach max alerts
+// or latency is critical for the threshold count and recovery notification when the down target becomes healthy again.
+func (s *Scheduler) check(ctx context.Context, t Target, st *state, r Result) {
+	defer func() { s.record(t, st, r) }() // r is updated by latency threshold
+	s.checkCert(ctx, t, st, r)
+	if r.change != "" {
+		log.Printf("[INFO] target %s %s", t.Name, r.change)
+		s.notify(ctx, noticeAlert(t, r))
+	}
+	if r.OK() && t.Latency.Warning > 0 && r.Timings.Total > t.Latency.Warning {
+		log.Printf("[WARN] target %s is slow: %s", t.Name, r.Timings)
+	}
+	// the target slow for Count probes is alerted at once, max alerts are already counted by latency threshold
+	slow := false
+	if r.OK() {
+		r.Err = t.Latency.check(st, r.Timings)
+		slow = r.Err != nil
+	}
+	if r.OK() {
+		if st.down {
+			log.Printf("[INFO] target %s is recovered", t.Name)
+			a := alert(t, st, r, provider.StateRecovered)
+			a.Downtime = r.Time.Sub(st.downSince).Round(time.Second)
+			a.ResolvedAt = r.Time
+			s.notify(ctx, a)
+		}
+		st.failures, st.consecutive, st.down, st.downSince = 0, 0, false, time.Time{}
+		return
+	}
+	if r.Cert.failsHandshake() && st.certKind == r.Cert.kind {
+		// the handshake failure is already alerted as the certificate problem
+		return
+	}
+	if st.downSince.IsZero() {
+		st.downSince = r.Time
+	}
+	st.consecutive++
+	if st.failures >= t.MaxAlerts || (slow && !st.down) {
+		log.Printf("[INFO] target %s is down", t.Name)
+		s.notify(ctx, alert(t, st, r, provider.StateDown))
+		st.failures = 0
+		st.down = true
+	}
+	st.failures++
+}
+
+// checkCert sends the certificate alert once per problem kind and recovery when the certificate is valid again.
+// The certificate state is kept if there was no handshake.
+func (s *Scheduler) checkCert(ctx context.Context, t Target, st *state, r Result) {
+	if r.Cert == nil {
+		return
+	}
+	if r.Cert.Problem != "" {
+		if st.certSince.IsZero() {
+			st.certSince = r.Time
+		}
+		if st.certKind != r.Cert.kind {
+			log.Printf("[INFO] target %s has certificate problem: %s", t.Name, r.Cert.Problem)
+			st.certKind = r.Cert.kind
+			a := certAlert(t, r, provider.StateDown)
+			a.StartedAt = st.certSince
+			s.notify(ctx, a)
+		}
+		return
+	}
+	if st.certKind != "" {
+		log.Printf("[INFO] target %s certificate is recovered", t.Name)
+		a := certAlert(t, r, provider.StateRecovered)
+		a.StartedAt = st.certSince
+		a.Downtime = r.Time.Sub(st.certSince).Round(time.Second)
+		a.ResolvedAt = r.Time
+		s.notify(ctx, a)
+	}
+	st.certKind, st.certSince = "", time.Time{}
+}
+
+func certAlert(t Target, r Result, state provider.State) provider.Alert {
+	return provider.Alert{
+		Target:     t.Name,
+		URL:        t.URL,
+		Type:       provider.TypeCertificate,
+		State:      state,
+		Reason:     r.Cert.Problem,
+		StatusCode: r.StatusCode,
+		Latency:    r.Latency.Round(time.Millisecond),
+		Time:       r.Time,
+		CertExpiry: r.Cert.NotAfter,
+	}
+}
+
+// noticeAlert makes the alert of the dns answer change, it doesn't change the target state
+func noticeAlert(t Target, r Result) provider.Alert {
+	return provider.Alert{
+		Target:  t.Name,
+		URL:     t.URL,
+		Type:    provider.TypeDNS,
+		State:   provider.StateChanged,
+		Reason:  r.change,
+		Latency: r.Latency.Round(time.Millisecond),
+		Time:    r.Time,
+	}
+}
+
+// alert makes the provider alert with the probe context
+func alert(t Target, st *state, r Result, state provider.State) provider.Alert {
+	a := provider.Alert{
+		Target:     t.Name,
+		URL:        t.URL,
+		Type:       provider.TypeAvailability,
+		State:      state,
+		Reason:     r.Reason(),
+		StatusCode: r.StatusCode,
+		Latency:    r.Latency.Round(time.Millisecond),
+		Failures:   st.consecutive,
+		Time:       r.Time,
+		StartedAt:  st.downSince,
+	}
+	if r.Err != nil {
+		a.Error = r.Err.Error()
+	}
+	if r.Cert != nil {
+		a.CertExpiry = r.Cert.NotAfter
+	}
+	return a
+}
+
+// notify sends the alert by all providers concurrently, so the hung provider doesn't delay others
+func (s *Scheduler) notify(ctx context.Context, a provider.Alert) {
+	if a.Type != provider.TypeDigest {
+		s.statuses.alerted(a.Target, time.Now())
+	}
+	var wg sync.WaitGroup
+	for _, p := range s.Providers {
+		wg.Add(1)
+		go func(p provider.Interface) {
+			defer wg.Done()
+			sendCtx, cancel := s.sendContext(ctx)
+			defer cancel()
+			err := p.Send(sendCtx, a)
+			s.notifyCounts.add(p.GetID(), err)
+			if err != nil {
+				log.Printf("[ERROR] error occurs during sending [%s] message for target %s: %+v", p.GetID(), a.Target, err)
+			}
+		}(p)
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) sendContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.NotifyTimeout > 0 {
+		return context.WithTimeout(ctx, s.NotifyTimeout)
+	}
+	return context.WithCancel(ctx)
+}
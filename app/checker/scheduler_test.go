@@ -0,0 +1,157 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/theshamuel/hhchecker/app/provider"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockProvider struct {
+	sync.Mutex
+	sent []provider.Alert
+}
+
+func (m *mockProvider) Send(_ context.Context, a provider.Alert) error {
+	m.Lock()
+	defer m.Unlock()
+	m.sent = append(m.sent, a)
+	return nil
+}
+
+func (m *mockProvider) GetID() provider.ID {
+	return "mock"
+}
+
+func (m *mockProvider) notifications() []provider.Alert {
+	m.Lock()
+	defer m.Unlock()
+	return append([]provider.Alert{}, m.sent...)
+}
+
+var (
+	failed    = Result{StatusCode: http.StatusInternalServerError, Err: errors.New("bad status code 500"), Time: time.Now()}
+	succeeded = Result{StatusCode: http.StatusOK, Time: time.Now()}
+)
+
+func TestSchedulerAlertsOnlyFailedTarget(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer up.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer down.Close()
+
+	mock := &mockProvider{}
+	s := &Scheduler{
+		Targets: []Target{
+			{Name: "up", URL: up.URL, Interval: 10 * time.Millisecond, MaxAlerts: 1},
+			{Name: "down", URL: down.URL, Interval: 10 * time.Millisecond, MaxAlerts: 1},
+		},
+		Providers: []provider.Interface{mock},
+		Client:    &http.Client{},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	s.Run(ctx)
+
+	sent := mock.notifications()
+	assert.NotEmpty(t, sent)
+	for _, a := range sent {
+		assert.Equal(t, "down", a.Target)
+		assert.Equal(t, down.URL, a.URL)
+	}
+}
+
+func TestCheckCountsFailuresPerTarget(t *testing.T) {
+	mock := &mockProvider{}
+	s := &Scheduler{Providers: []provider.Interface{mock}}
+	target := Target{Name: "a", MaxAlerts: 2}
+	st := &state{}
+
+	s.check(context.Background(), target, st, failed)
+	s.check(context.Background(), target, st, failed)
+	assert.Empty(t, mock.notifications())
+	s.check(context.Background(), target, st, failed)
+	assert.Len(t, mock.notifications(), 1)
+	assert.Equal(t, int8(1), st.failures)
+
+	s.check(context.Background(), target, st, succeeded)
+	assert.Equal(t, int8(0), st.failures)
+}
+
+func TestCheckSendsRecoveryForDownTargetOnly(t *testing.T) {
+	mock := &mockProvider{}
+	s := &Scheduler{Providers: []provider.Interface{mock}}
+	target := Target{Name: "a", URL: "http://a", MaxAlerts: 1}
+	st := &state{}
+
+	s.check(context.Background(), target, st, failed)
+	s.check(context.Background(), target, st, succeeded)
+	assert.Empty(t, mock.notifications(), "no recovery without alert")
+
+	s.check(context.Background(), target, st, failed)
+	s.check(context.Background(), target, st, failed)
+	s.check(context.Background(), target, st, succeeded)
+
+	sent := mock.notifications()
+	assert.Len(t, sent, 2)
+	assert.Equal(t, provider.StateDown, sent[0].State)
+	assert.Equal(t, provider.StateRecovered, sent[1].State)
+	assert.Equal(t, "a", sent[1].Target)
+	assert.Equal(t, 2, sent[0].Failures)
+	assert.Equal(t, http.StatusInternalServerError, sent[0].StatusCode)
+	assert.Equal(t, "bad status code 500", sent[0].Reason)
+	assert.Equal(t, sent[0].StartedAt, sent[1].StartedAt)
+	assert.False(t, sent[1].ResolvedAt.IsZero())
+	assert.False(t, st.down)
+}
+
+type hungProvider struct{}
+
+func (h hungProvider) Send(ctx context.Context, _ provider.Alert) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (h hungProvider) GetID() provider.ID {
+	return "hung"
+}
+
+func TestNotifyCancelsHungProvider(t *testing.T) {
+	mock := &mockProvider{}
+	s := &Scheduler{
+		Providers:     []provider.Interface{hungProvider{}, mock},
+		NotifyTimeout: 50 * time.Millisecond,
+	}
+	st := time.Now()
+	s.notify(context.Background(), provider.Alert{Target: "a"})
+	assert.Less(t, time.Since(st), time.Second)
+	assert.Len(t, mock.notifications(), 1)
+}
+
+func TestProbeBodyAssertion(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html>maintenance: database is down</html>"))
+	}))
+	defer ts.Close()
+
+	s := &Scheduler{Client: ts.Client()}
+	r := s.probe(context.Background(), Target{Name: "a", URL: ts.URL, Body: BodyAssertion{NotContains: []string{"database is down"}}})
+	assert.False(t, r.OK())
+	assert.Equal(t, `body contains "database is down"`, r.Reason())
+
+	r = s.probe(context.Background(), Target{Name: "a", URL: ts.URL, Body: BodyAssertion{Contains: []string{"down"}, MaxSize: 10}})
+	assert.Equal(t, `body doesn't contain "down"`, r.Reason(), "body is truncated by max size")
+
+	r = s.probe(context.Background(), Target{Name: "a", URL: ts.URL, Body: BodyAssertion{Contains: []string{"maintenance"}}})
+	assert.True(t, r.OK())
+}
@@ -0,0 +1,141 @@
+package checker
+
+import (
+	"sync"
+	"time"
+)
+
+// states of the target in Status
+const (
+	StatusPending = "pending" // not probed yet
+	StatusUp      = "up"
+	StatusFailing = "failing" // the last probe failed but the target is not alerted as down yet
+	StatusDown    = "down"
+)
+
+// Status is the current state of the watched target
+type Status struct {
+	Name          string
+	Type          string
+	URL           string
+	State         string // one of Status* states
+	Consecutive   int    // failed probes in a row
+	Checks        int    // probes since the start
+	Failed        int    // failed probes since the start
+	Last          Result // the last probe, zero if pending
+	LastAlert     time.Time
+	DownSince     time.Time
+	WatchingSince time.Time
+	Durations     map[string]*Histogram // probe phase durations by dns, connect, tls, ttfb and total
+	Days          []Day                 // probes by day for the last HistoryDays days
+	Incidents     []Incident            // the last MaxIncidents incidents, the oldest first
+}
+
+// Uptime returns the percent of successful probes since the start, 100 if there was no probe
+func (s Status) Uptime() float64 {
+	if s.Checks == 0 {
+		return 100
+	}
+	return float64(s.Checks-s.Failed) * 100 / float64(s.Checks)
+}
+
+// clone returns the copy of status with own histograms
+func (s *Status) clone() Status {
+	res := *s
+	res.Durations = make(map[string]*Histogram, len(s.Durations))
+	for phase, h := range s.Durations {
+		c := *h
+		c.Buckets = append([]uint64(nil), h.Buckets...)
+		res.Durations[phase] = &c
+	}
+	res.Days = append([]Day(nil), s.Days...)
+	res.Incidents = append([]Incident(nil), s.Incidents...)
+	return res
+}
+
+// statuses keeps the status of every watched target
+type statuses struct {
+	sync.Mutex
+	byName map[string]*Status
+}
+
+// Statuses returns the copy of every watched target status in the order of targets
+func (s *Scheduler) Statuses() []Status {
+	s.statuses.Lock()
+	defer s.statuses.Unlock()
+	res := make([]Status, 0, len(s.statuses.byName))
+	for _, t := range s.Targets {
+		if st, ok := s.statuses.byName[t.Name]; ok {
+			res = append(res, st.clone())
+		}
+	}
+	return res
+}
+
+// Status returns the status of the target by name
+func (s *Scheduler) Status(name string) (Status, bool) {
+	s.statuses.Lock()
+	defer s.statuses.Unlock()
+	st, ok := s.statuses.byName[name]
+	if !ok {
+		return Status{}, false
+	}
+	return st.clone(), true
+}
+
+// start adds the pending status of the target with days and incidents restored from history
+func (ss *statuses) start(t Target, now time.Time, days []Day, incidents []Incident) {
+	ss.Lock()
+	defer ss.Unlock()
+	if ss.byName == nil {
+		ss.byName = map[string]*Status{}
+	}
+	typ := t.Type
+	if typ == "" {
+		typ = TypeHTTP
+	}
+	if len(days) > HistoryDays {
+		days = days[len(days)-HistoryDays:]
+	}
+	if len(incidents) > MaxIncidents {
+		incidents = incidents[len(incidents)-MaxIncidents:]
+	}
+	ss.byName[t.Name] = &Status{Name: t.Name, Type: typ, URL: t.URL, State: StatusPending, WatchingSince: now,
+		Durations: map[string]*Histogram{}, Days: days, Incidents: incidents}
+}
+
+// update records the checked probe and the target state after check, returns the changed incident
+func (ss *statuses) update(t Target, st *state, r Result) *Incident {
+	ss.Lock()
+	defer ss.Unlock()
+	status, ok := ss.byName[t.Name]
+	if !ok {
+		return nil
+	}
+	status.Checks++
+	status.Last = r
+	status.Consecutive = st.consecutive
+	status.DownSince = st.downSince
+	observeTimings(status.Durations, r.Timings)
+	status.record(r)
+	prev := status.State
+	switch {
+	case r.OK():
+		status.State = StatusUp
+	case st.down:
+		status.State = StatusDown
+		status.Failed++
+	default:
+		status.State = StatusFailing
+		status.Failed++
+	}
+	return status.transition(prev, st, r)
+}
+
+func (ss *statuses) alerted(name string, at time.Time) {
+	ss.Lock()
+	defer ss.Unlock()
+	if status, ok := ss.byName[name]; ok {
+		status.LastAlert = at
+	}
+}
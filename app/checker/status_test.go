@@ -0,0 +1,49 @@
+package checker
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/theshamuel/hhchecker/app/provider"
+	"testing"
+)
+
+func TestStatuses(t *testing.T) {
+	target := Target{Name: "a", URL: "http://a", MaxAlerts: 1}
+	s := &Scheduler{Targets: []Target{target, {Name: "b"}}, Providers: []provider.Interface{&mockProvider{}}}
+	st := &state{}
+	s.start(target)
+
+	res := s.Statuses()
+	assert.Len(t, res, 1, "only watched targets")
+	assert.Equal(t, StatusPending, res[0].State)
+	assert.Equal(t, TypeHTTP, res[0].Type)
+	assert.Equal(t, float64(100), res[0].Uptime())
+
+	s.check(context.Background(), target, st, succeeded)
+	s.check(context.Background(), target, st, failed)
+	status, ok := s.Status("a")
+	assert.True(t, ok)
+	assert.Equal(t, StatusFailing, status.State)
+	assert.Equal(t, 1, status.Consecutive)
+	assert.True(t, status.LastAlert.IsZero())
+
+	s.check(context.Background(), target, st, failed)
+	status, _ = s.Status("a")
+	assert.Equal(t, StatusDown, status.State)
+	assert.Equal(t, 2, status.Consecutive)
+	assert.Equal(t, 3, status.Checks)
+	assert.Equal(t, 2, status.Failed)
+	assert.InDelta(t, 33.3, status.Uptime(), 0.1)
+	assert.False(t, status.LastAlert.IsZero())
+	assert.Equal(t, failed.Time, status.DownSince)
+	assert.Equal(t, "bad status code 500", status.Last.Reason())
+
+	s.check(context.Background(), target, st, succeeded)
+	status, _ = s.Status("a")
+	assert.Equal(t, StatusUp, status.State)
+	assert.Equal(t, 0, status.Consecutive)
+	assert.True(t, status.DownSince.IsZero())
+
+	_, ok = s.Status("b")
+	assert.False(t, ok)
+}
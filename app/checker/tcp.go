@@ -0,0 +1,73 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"time"
+)
+
+// TCPCheck describes the optional exchange after the connection is established
+type TCPCheck struct {
+	Send   string // the payload written after connect, e.g. "PING\r\n"
+	Expect string // the prefix of the response, the response is not read if empty
+}
+
+// Address returns host:port of the target url, tcp:// scheme is allowed
+func Address(url string) (string, error) {
+	addr := strings.TrimPrefix(url, "tcp://")
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return "", fmt.Errorf("address %q is not host:port: %w", url, err)
+	}
+	return addr, nil
+}
+
+// probeTCP dials the target address and checks the response prefix if expected
+func probeTCP(ctx context.Context, t Target) Result {
+	r := Result{Time: time.Now()}
+	ctx, cancel := context.WithTimeout(ctx, t.timeout())
+	defer cancel()
+	addr, err := Address(t.URL)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	r.Timings.Connect = time.Since(r.Time)
+	r.Timings.Total, r.Latency = r.Timings.Connect, r.Timings.Connect
+	if err != nil {
+		log.Printf("[DEBUG] target %s get error: %v", t.Name, err)
+		r.Err = t.probeError(err)
+		return r
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if t.TCP.Send != "" {
+		if _, err = io.WriteString(conn, t.TCP.Send); err != nil {
+			r.Err = t.probeError(fmt.Errorf("can't send payload: %w", err))
+			return r
+		}
+	}
+	if t.TCP.Expect == "" {
+		return r
+	}
+	got := make([]byte, len(t.TCP.Expect))
+	n, err := io.ReadFull(conn, got)
+	r.Timings.Total = time.Since(r.Time)
+	r.Timings.TTFB, r.Latency = r.Timings.Total, r.Timings.Total
+	if err != nil {
+		r.Err = t.probeError(fmt.Errorf("can't read response, got %q: %w", got[:n], err))
+		return r
+	}
+	if !bytes.Equal(got, []byte(t.TCP.Expect)) {
+		r.Err = fmt.Errorf("response %q doesn't start with %q", got, t.TCP.Expect)
+	}
+	return r
+}
@@ -0,0 +1,61 @@
+package checker
+
+import (
+	"bufio"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestProbeTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				line, err := bufio.NewReader(conn).ReadString('\n')
+				if err == nil && line == "PING\r\n" {
+					_, _ = conn.Write([]byte("+PONG\r\n"))
+				}
+			}(conn)
+		}
+	}()
+	s := &Scheduler{}
+	addr := ln.Addr().String()
+
+	r := s.probe(context.Background(), Target{Name: "redis", Type: TypeTCP, URL: addr})
+	assert.True(t, r.OK(), r.Reason())
+	assert.True(t, r.Timings.Total > 0)
+
+	r = s.probe(context.Background(), Target{Name: "redis", Type: TypeTCP, URL: "tcp://" + addr,
+		TCP: TCPCheck{Send: "PING\r\n", Expect: "+PONG"}})
+	assert.True(t, r.OK(), r.Reason())
+
+	r = s.probe(context.Background(), Target{Name: "redis", Type: TypeTCP, URL: addr,
+		TCP: TCPCheck{Send: "PING\r\n", Expect: "-ERR"}})
+	assert.EqualError(t, r.Err, `response "+PON" doesn't start with "-ERR"`)
+
+	r = s.probe(context.Background(), Target{Name: "redis", Type: TypeTCP, URL: addr, Timeout: 50 * time.Millisecond,
+		TCP: TCPCheck{Send: "HELLO\n", Expect: "+PONG"}})
+	assert.Contains(t, r.Reason(), "can't read response")
+
+	r = s.probe(context.Background(), Target{Name: "redis", Type: TypeTCP, URL: addr, Timeout: 50 * time.Millisecond,
+		TCP: TCPCheck{Expect: "+PONG"}})
+	assert.EqualError(t, r.Err, "timeout after 50ms")
+
+	r = s.probe(context.Background(), Target{Name: "pg", Type: TypeTCP, URL: "127.0.0.1:1"})
+	assert.False(t, r.OK())
+
+	r = s.probe(context.Background(), Target{Name: "pg", Type: TypeTCP, URL: "postgres"})
+	assert.Contains(t, r.Reason(), `address "postgres" is not host:port`)
+}
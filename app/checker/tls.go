@@ -0,0 +1,107 @@
+package checker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// TLS settings of HTTPS target, the system roots and Go defaults are used if empty
+type TLS struct {
+	ExpiryDays         int    // days before the leaf certificate expiry to alert, DefaultExpiryDays if 0
+	CAFile             string // PEM bundle of extra CA trusted in addition to the system roots
+	CertFile           string // PEM client certificate for mutual TLS, requires KeyFile
+	KeyFile            string
+	ServerName         string // SNI and the name verified in the certificate instead of the URL host
+	MinVersion         string // one of 1.0, 1.1, 1.2 or 1.3
+	InsecureSkipVerify bool   // chain and hostname are not verified, expiry is still alerted
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// custom reports whether any setting of the connection is changed
+func (t TLS) custom() bool {
+	return t.CAFile != "" || t.CertFile != "" || t.KeyFile != "" || t.ServerName != "" ||
+		t.MinVersion != "" || t.InsecureSkipVerify
+}
+
+// Config applies the settings to the copy of base config, base can be nil
+func (t TLS) Config(base *tls.Config) (*tls.Config, error) {
+	cfg := &tls.Config{}
+	if base != nil {
+		cfg = base.Clone()
+	}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't read ca file: %w", err)
+		}
+		pool := cfg.RootCAs
+		if pool == nil {
+			if pool, err = x509.SystemCertPool(); err != nil {
+				pool = x509.NewCertPool()
+			}
+		}
+		pool = pool.Clone()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ca file %s has no PEM certificates", t.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return nil, fmt.Errorf("both cert file and key file are required for client certificate")
+	}
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	if t.MinVersion != "" {
+		v, ok := tlsVersions[t.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("tls min version %q is not one of 1.0, 1.1, 1.2, 1.3", t.MinVersion)
+		}
+		cfg.MinVersion = v
+	}
+	if t.ServerName != "" {
+		cfg.ServerName = t.ServerName
+	}
+	cfg.InsecureSkipVerify = cfg.InsecureSkipVerify || t.InsecureSkipVerify
+	return cfg, nil
+}
+
+// httpClient returns the scheduler client or its copy with own transport for the target TLS settings and type
+func (s *Scheduler) httpClient(t Target) (*http.Client, error) {
+	if t.Type == TypeGRPC {
+		return s.grpcClient(t)
+	}
+	client := s.Client
+	if t.TLS.custom() {
+		transport, ok := s.Client.Transport.(*http.Transport)
+		if !ok || transport == nil {
+			transport = http.DefaultTransport.(*http.Transport)
+		}
+		transport = transport.Clone()
+		cfg, err := t.TLS.Config(transport.TLSClientConfig)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = cfg
+		custom := *s.Client
+		custom.Transport = transport
+		client = &custom
+	}
+	if t.Type == TypeWebSocket {
+		client = wsClient(client)
+	}
+	return client, nil
+}
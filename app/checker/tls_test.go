@@ -0,0 +1,114 @@
+package checker
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProbeMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	clientCert, clientKey := writeClientCert(t, dir)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	ts.StartTLS()
+	defer ts.Close()
+	caFile := filepath.Join(dir, "ca.pem")
+	err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0o600)
+	assert.NoError(t, err)
+	s := &Scheduler{Client: &http.Client{}}
+
+	target := Target{Name: "a", URL: ts.URL, TLS: TLS{CAFile: caFile}}
+	target.client, err = s.httpClient(target)
+	assert.NoError(t, err)
+	r := s.probe(context.Background(), target)
+	assert.False(t, r.OK(), "client certificate is required")
+
+	target.TLS = TLS{CAFile: caFile, CertFile: clientCert, KeyFile: clientKey, MinVersion: "1.2"}
+	target.client, err = s.httpClient(target)
+	assert.NoError(t, err)
+	r = s.probe(context.Background(), target)
+	assert.True(t, r.OK(), r.Reason())
+	assert.Empty(t, r.Cert.Problem)
+
+	target.TLS = TLS{CertFile: clientCert, KeyFile: clientKey}
+	target.client, err = s.httpClient(target)
+	assert.NoError(t, err)
+	r = s.probe(context.Background(), target)
+	assert.False(t, r.OK(), "server certificate is not trusted without ca file")
+
+	target.TLS = TLS{CertFile: clientCert, KeyFile: clientKey, InsecureSkipVerify: true}
+	target.client, err = s.httpClient(target)
+	assert.NoError(t, err)
+	r = s.probe(context.Background(), target)
+	assert.True(t, r.OK(), r.Reason())
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	clientCert, clientKey := writeClientCert(t, dir)
+
+	cfg, err := TLS{ServerName: "internal.local", MinVersion: "1.3", CertFile: clientCert, KeyFile: clientKey}.Config(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "internal.local", cfg.ServerName)
+	assert.Equal(t, uint16(tls.VersionTLS13), cfg.MinVersion)
+	assert.Len(t, cfg.Certificates, 1)
+
+	_, err = TLS{MinVersion: "1.4"}.Config(nil)
+	assert.EqualError(t, err, `tls min version "1.4" is not one of 1.0, 1.1, 1.2, 1.3`)
+	_, err = TLS{CertFile: clientCert}.Config(nil)
+	assert.EqualError(t, err, "both cert file and key file are required for client certificate")
+	_, err = TLS{CAFile: filepath.Join(dir, "missing.pem")}.Config(nil)
+	assert.Error(t, err)
+	_, err = TLS{CAFile: clientKey}.Config(nil)
+	assert.Contains(t, err.Error(), "has no PEM certificates")
+
+	s := &Scheduler{Client: &http.Client{}}
+	client, err := s.httpClient(Target{TLS: TLS{ExpiryDays: 7}})
+	assert.NoError(t, err)
+	assert.True(t, client == s.Client, "the shared client is used without tls settings")
+}
+
+// writeClientCert writes self-signed client certificate and key into dir and returns the file names
+func writeClientCert(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "hhchecker"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile = filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
@@ -0,0 +1,254 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WebSocketCheck describes the optional exchange after the upgrade handshake
+type WebSocketCheck struct {
+	Send   string // the text message sent after the handshake
+	Expect string // the substring of the expected reply, the reply is not awaited if empty
+}
+
+// websocket frame opcodes
+const (
+	wsText  = 0x1
+	wsClose = 0x8
+	wsPing  = 0x9
+	wsPong  = 0xA
+)
+
+const (
+	wsGUID     = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	wsMaxReply = 1 << 20
+)
+
+// wsURL converts ws and wss schemes to http and https for the handshake request
+func wsURL(url string) string {
+	switch {
+	case strings.HasPrefix(url, "ws://"):
+		return "http://" + strings.TrimPrefix(url, "ws://")
+	case strings.HasPrefix(url, "wss://"):
+		return "https://" + strings.TrimPrefix(url, "wss://")
+	}
+	return url
+}
+
+// wsClient returns the copy of the target client limited to HTTP/1.1, the upgrade is not possible over HTTP/2
+func wsClient(client *http.Client) *http.Client {
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok || transport == nil {
+		transport = http.DefaultTransport.(*http.Transport)
+	}
+	transport = transport.Clone()
+	transport.ForceAttemptHTTP2 = false
+	transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	if transport.TLSClientConfig != nil {
+		transport.TLSClientConfig.NextProtos = nil
+	}
+	res := *client
+	res.Transport = transport
+	return &res
+}
+
+// probeWebSocket performs the upgrade handshake and waits for the expected reply
+func (s *Scheduler) probeWebSocket(ctx context.Context, t Target) Result {
+	r := Result{Time: time.Now()}
+	ctx, cancel := context.WithTimeout(ctx, t.timeout())
+	defer cancel()
+	client := t.client
+	if client == nil {
+		var err error
+		if client, err = s.httpClient(t); err != nil {
+			r.Err = err
+			return r
+		}
+	}
+	tr := newTracer(r.Time)
+	req, err := t.Request.build(tr.context(ctx), wsURL(t.URL))
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	nonce := make([]byte, 16)
+	if _, err = rand.Read(nonce); err != nil {
+		r.Err = err
+		return r
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	response, err := client.Do(req)
+	r.Timings = tr.result()
+	r.Latency = r.Timings.Total
+	if err != nil {
+		log.Printf("[DEBUG] target %s get error: %v", t.Name, err)
+		r.Err = t.probeError(err)
+		r.Cert = certFromError(err)
+		return r
+	}
+	defer response.Body.Close()
+	r.Cert = inspectCert(response.TLS, t.TLS.ExpiryDays, r.Time)
+	r.StatusCode = response.StatusCode
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		r.Err = fmt.Errorf("websocket handshake failed with status code %d", response.StatusCode)
+		return r
+	}
+	if response.Header.Get("Sec-WebSocket-Accept") != wsAccept(key) {
+		r.Err = errors.New("websocket handshake failed: bad Sec-WebSocket-Accept")
+		return r
+	}
+	conn, ok := response.Body.(io.ReadWriteCloser)
+	if !ok {
+		r.Err = errors.New("websocket handshake failed: connection is not upgraded")
+		return r
+	}
+	// the upgraded connection has no deadline, so it is closed on timeout to unblock reading
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	r.Err = t.WebSocket.exchange(conn)
+	r.Timings.Total = time.Since(r.Time)
+	r.Latency = r.Timings.Total
+	if r.Err != nil && ctx.Err() != nil {
+		r.Err = fmt.Errorf("timeout after %s waiting for websocket reply", t.timeout())
+	}
+	_ = wsWrite(conn, wsClose, []byte{0x03, 0xe8}) // normal closure
+	return r
+}
+
+// exchange sends the message and reads frames until the expected reply
+func (w WebSocketCheck) exchange(conn io.ReadWriter) error {
+	if w.Send != "" {
+		if err := wsWrite(conn, wsText, []byte(w.Send)); err != nil {
+			return fmt.Errorf("can't send websocket message: %w", err)
+		}
+	}
+	if w.Expect == "" {
+		return nil
+	}
+	var message []byte
+	for {
+		fin, opcode, payload, err := wsRead(conn)
+		if err != nil {
+			return fmt.Errorf("can't read websocket reply: %w", err)
+		}
+		switch opcode {
+		case wsPing:
+			if err = wsWrite(conn, wsPong, payload); err != nil {
+				return fmt.Errorf("can't send websocket pong: %w", err)
+			}
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			return errors.New("websocket is closed by server before the expected reply")
+		}
+		if message = append(message, payload...); len(message) > wsMaxReply {
+			return errors.New("websocket reply is too large")
+		}
+		if !fin {
+			continue
+		}
+		if bytes.Contains(message, []byte(w.Expect)) {
+			return nil
+		}
+		log.Printf("[DEBUG] websocket reply %q doesn't contain %q", message, w.Expect)
+		message = message[:0]
+	}
+}
+
+func wsAccept(key string) string {
+	h := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// wsWrite writes the single masked frame as required for the client
+func wsWrite(w io.Writer, opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+	switch l := len(payload); {
+	case l < 126:
+		frame = append(frame, 0x80|byte(l))
+	case l <= 0xffff:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(l))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(l))
+	}
+	mask := make([]byte, 4)
+	if _, err := rand.Read(mask); err != nil {
+		return err
+	}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := w.Write(frame)
+	return err
+}
+
+// wsRead reads the single frame and unmasks its payload
+func wsRead(r io.Reader) (fin bool, opcode byte, payload []byte, err error) {
+	header := make([]byte, 2)
+	if _, err = io.ReadFull(r, header); err != nil {
+		return false, 0, nil, err
+	}
+	fin, opcode = header[0]&0x80 != 0, header[0]&0x0f
+	size := uint64(header[1] & 0x7f)
+	switch size {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err = io.ReadFull(r, ext); err != nil {
+			return false, 0, nil, err
+		}
+		size = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err = io.ReadFull(r, ext); err != nil {
+			return false, 0, nil, err
+		}
+		size = binary.BigEndian.Uint64(ext)
+	}
+	if size > wsMaxReply {
+		return false, 0, nil, errors.New("websocket frame is too large")
+	}
+	var mask []byte
+	if header[1]&0x80 != 0 {
+		mask = make([]byte, 4)
+		if _, err = io.ReadFull(r, mask); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload = make([]byte, size)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if mask != nil {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
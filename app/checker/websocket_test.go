@@ -0,0 +1,80 @@
+package checker
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wsEchoHandler upgrades the connection and replies to every text message by "echo: <message>" after the ping
+func wsEchoHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/plain" || r.Header.Get("Upgrade") != "websocket" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + wsAccept(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+		_ = rw.Flush()
+		for {
+			_, opcode, payload, err := wsRead(rw)
+			if err != nil || opcode == wsClose {
+				return
+			}
+			if opcode != wsText || string(payload) == "silence" {
+				continue
+			}
+			// the server frames are not masked
+			_, _ = rw.Write([]byte{0x80 | wsPing, 0})
+			reply := "echo: " + string(payload)
+			_, _ = rw.Write(append([]byte{0x80 | wsText, byte(len(reply))}, reply...))
+			_ = rw.Flush()
+		}
+	}
+}
+
+func TestProbeWebSocket(t *testing.T) {
+	ts := httptest.NewServer(wsEchoHandler(t))
+	defer ts.Close()
+	s := &Scheduler{Client: &http.Client{}}
+	url := "ws://" + strings.TrimPrefix(ts.URL, "http://")
+
+	r := s.probe(context.Background(), Target{Name: "ws", Type: TypeWebSocket, URL: url})
+	assert.True(t, r.OK(), r.Reason())
+	assert.Equal(t, http.StatusSwitchingProtocols, r.StatusCode)
+
+	r = s.probe(context.Background(), Target{Name: "ws", Type: TypeWebSocket, URL: url,
+		WebSocket: WebSocketCheck{Send: "hello", Expect: "echo: hello"}})
+	assert.True(t, r.OK(), r.Reason())
+
+	r = s.probe(context.Background(), Target{Name: "ws", Type: TypeWebSocket, URL: url, Timeout: 50 * time.Millisecond,
+		WebSocket: WebSocketCheck{Send: "silence", Expect: "echo"}})
+	assert.EqualError(t, r.Err, "timeout after 50ms waiting for websocket reply")
+
+	r = s.probe(context.Background(), Target{Name: "ws", Type: TypeWebSocket, URL: url + "/plain"})
+	assert.EqualError(t, r.Err, "websocket handshake failed with status code 200")
+}
+
+func TestWebSocketTLS(t *testing.T) {
+	ts := httptest.NewUnstartedServer(wsEchoHandler(t))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+	s := &Scheduler{Client: ts.Client()}
+
+	target := Target{Name: "wss", Type: TypeWebSocket, URL: "wss://" + strings.TrimPrefix(ts.URL, "https://"),
+		WebSocket: WebSocketCheck{Send: "ping", Expect: "echo: ping"}}
+	r := s.probe(context.Background(), target)
+	assert.True(t, r.OK(), r.Reason())
+	assert.NotNil(t, r.Cert)
+}
@@ -2,16 +2,19 @@ package config
 
 import (
 	"fmt"
+	"github.com/theshamuel/hhchecker/app/checker"
 	"github.com/theshamuel/hhchecker/app/provider"
+	"github.com/theshamuel/hhchecker/app/server"
 	"gopkg.in/yaml.v3"
-	"io"
 	"net/http"
 	"os"
-	"strings"
 	"sync"
 	"time"
 )
 
+// urlComponent is the status page name of the top level url, the url itself is not shown
+const urlComponent = "Service"
+
 type Config struct {
 	FileName string
 	sync.Mutex
@@ -19,21 +22,35 @@ type Config struct {
 }
 
 type File struct {
-	URL       string        `yaml:"url"`
-	Timeout   time.Duration `yaml:"timeout,omitempty"`
-	MaxAlerts int8          `yaml:"max-alerts,omitempty"`
-	Debug     bool          `yaml:"debug,omitempty"`
-	Email     struct {
-		Enabled bool   `yaml:"enabled,omitempty"`
-		From    string `yaml:"from,omitempty"`
-		To      string `yaml:"to,omitempty"`
-		Cc      string `yaml:"cc,omitempty"`
-		Subject string `yaml:"subject,omitempty"`
-		Text    string `yaml:"text,omitempty"`
-		Mailgun struct {
+	URL            string        `yaml:"url"`
+	Timeout        time.Duration `yaml:"timeout,omitempty"`
+	MaxAlerts      int8          `yaml:"max-alerts,omitempty"`
+	Debug          bool          `yaml:"debug,omitempty"`
+	RequestTimeout time.Duration `yaml:"request-timeout,omitempty"`
+	CertExpiryDays int           `yaml:"cert-expiry-days,omitempty"`
+	Listen         string        `yaml:"listen,omitempty"`
+	Targets        []Target      `yaml:"targets,omitempty"`
+	Email          struct {
+		Enabled  bool   `yaml:"enabled,omitempty"`
+		Provider string `yaml:"provider,omitempty"`
+		From     string `yaml:"from,omitempty"`
+		To       string `yaml:"to,omitempty"`
+		Cc       string `yaml:"cc,omitempty"`
+		Subject  string `yaml:"subject,omitempty"`
+		Text     string `yaml:"text,omitempty"`
+		HTML     string `yaml:"html,omitempty"`
+		Mailgun  struct {
 			Domain string `yaml:"domain,omitempty"`
 			APIKey string `yaml:"api-key,omitempty"`
 		} `yaml:"mailgun,omitempty"`
+		SMTP struct {
+			Host     string `yaml:"host,omitempty"`
+			Port     int    `yaml:"port,omitempty"`
+			Security string `yaml:"security,omitempty"`
+			Auth     string `yaml:"auth,omitempty"`
+			Username string `yaml:"username,omitempty"`
+			Password string `yaml:"password,omitempty"`
+		} `yaml:"smtp,omitempty"`
 	} `yaml:"email,omitempty"`
 	Telegram struct {
 		Enabled   bool   `yaml:"enabled,omitempty"`
@@ -44,56 +61,207 @@ type File struct {
 			ID   string `yaml:"id,omitempty"`
 		} `yaml:"channel,omitempty"`
 	} `yaml:"telegram,omitempty"`
+	Slack struct {
+		Enabled    bool   `yaml:"enabled,omitempty"`
+		WebhookURL string `yaml:"webhook-url,omitempty"`
+		Message    string `yaml:"message,omitempty"`
+	} `yaml:"slack,omitempty"`
+	Webhook struct {
+		Enabled bool              `yaml:"enabled,omitempty"`
+		Method  string            `yaml:"method,omitempty"`
+		URL     string            `yaml:"url,omitempty"`
+		Headers map[string]string `yaml:"headers,omitempty"`
+		Body    string            `yaml:"body,omitempty"`
+		Secret  string            `yaml:"secret,omitempty"`
+	} `yaml:"webhook,omitempty"`
+	Store struct {
+		Path         string        `yaml:"path,omitempty"`
+		Retention    time.Duration `yaml:"retention,omitempty"`
+		RawRetention time.Duration `yaml:"raw-retention,omitempty"`
+	} `yaml:"store,omitempty"`
+	Digests []struct {
+		Title    string `yaml:"title,omitempty"`
+		Schedule string `yaml:"schedule"`
+	} `yaml:"digests,omitempty"`
+	StatusPage struct {
+		Enabled    bool   `yaml:"enabled,omitempty"`
+		Title      string `yaml:"title,omitempty"`
+		Listen     string `yaml:"listen,omitempty"`
+		Components []struct {
+			Name    string   `yaml:"name"`
+			Targets []string `yaml:"targets"`
+		} `yaml:"components,omitempty"`
+	} `yaml:"status-page,omitempty"`
 }
 
 type CommonOpts struct {
-	URL       string        `long:"url" env:"URL" description:"the URL what you need to healthcheck"`
-	Timeout   time.Duration `long:"timeout" env:"TIMEOUT" default:"300s" description:"the timeout for health probe in seconds"`
-	MaxAlerts int8          `long:"max-alerts" env:"MAX_ALERTS" default:"3" description:"the max count of alerts in sequence"`
-	Debug     bool          `long:"debug" env:"DEBUG" description:"debug mode"`
+	URL            string        `long:"url" env:"URL" description:"the URL what you need to healthcheck"`
+	Timeout        time.Duration `long:"timeout" env:"TIMEOUT" default:"300s" description:"the timeout for health probe in seconds"`
+	RequestTimeout time.Duration `long:"request-timeout" env:"REQUEST_TIMEOUT" default:"10s" description:"the deadline of a single health probe request"`
+	CertExpiryDays int           `long:"cert-expiry-days" env:"CERT_EXPIRY_DAYS" default:"14" description:"days before the https certificate expiry to alert"`
+	MaxAlerts      int8          `long:"max-alerts" env:"MAX_ALERTS" default:"3" description:"the max count of alerts in sequence"`
+	Listen         string        `long:"listen" env:"LISTEN" description:"the address of http server for status api, metrics and heartbeats, e.g. :8080, disabled if empty"`
+	Digests        []string      `long:"digest" env:"DIGEST" env-delim:";" description:"the cron expression of digest sent by all providers, can be repeated"`
+	StatusPage     string        `long:"status-page" env:"STATUS_PAGE" description:"the title of public status page on /status of http server, disabled if empty"`
+	StatusListen   string        `long:"status-page-listen" env:"STATUS_PAGE_LISTEN" description:"the own address of status page without status api, metrics and heartbeats, e.g. :8081"`
+	Store          string        `long:"store" env:"STORE" description:"the file of probe history and incidents, kept in memory only if empty"`
+	Retention      time.Duration `long:"retention" env:"RETENTION" default:"2160h" description:"how long the history is kept in the store"`
+	RawRetention   time.Duration `long:"raw-retention" env:"RAW_RETENTION" default:"24h" description:"how long every probe is kept before downsampling into daily uptime"`
+	Debug          bool          `long:"debug" env:"DEBUG" description:"debug mode"`
+}
+
+// Target makes the single target defined by the common options
+func (o *CommonOpts) Target() checker.Target {
+	return checker.Target{
+		Name:      o.URL,
+		URL:       o.URL,
+		Interval:  o.Timeout,
+		Timeout:   o.RequestTimeout,
+		MaxAlerts: o.MaxAlerts,
+		TLS:       checker.TLS{ExpiryDays: o.CertExpiryDays},
+	}
+}
+
+// GetStatusPage makes the status page of the single target defined by the common options, nil if the page is disabled
+func (o *CommonOpts) GetStatusPage() *server.StatusPage {
+	if o.StatusPage == "" {
+		return nil
+	}
+	return &server.StatusPage{Title: o.StatusPage, Listen: o.StatusListen,
+		Components: []server.Component{{Name: urlComponent, Targets: []string{o.URL}}}}
 }
 
 func (s *Config) GetCommon() (*CommonOpts, error) {
 	s.Lock()
 	defer s.Unlock()
-	f, err := os.Open(s.FileName)
-	if err != nil {
-		return nil, fmt.Errorf("can't open %s: %w", s.FileName, err)
-	}
-	defer f.Close()
-	if err = yaml.NewDecoder(f).Decode(&s.File); err != nil {
-		return nil, fmt.Errorf("can't parse %s: %w", s.FileName, err)
+	if err := s.read(); err != nil {
+		return nil, err
 	}
 
 	return &CommonOpts{
-		URL:       s.File.URL,
-		Timeout:   s.File.Timeout,
-		MaxAlerts: s.File.MaxAlerts,
-		Debug:     s.File.Debug,
+		URL:            s.File.URL,
+		Timeout:        s.File.Timeout,
+		RequestTimeout: s.File.RequestTimeout,
+		CertExpiryDays: s.File.CertExpiryDays,
+		MaxAlerts:      s.File.MaxAlerts,
+		Listen:         s.File.Listen,
+		Store:          s.File.Store.Path,
+		Retention:      s.File.Store.Retention,
+		RawRetention:   s.File.Store.RawRetention,
+		Debug:          s.File.Debug,
 	}, nil
 }
 
-func (s *Config) GetProviders(client *http.Client) ([]provider.Interface, error) {
+// GetTargets returns all targets from the config file.
+// The top level url is kept as one more target for backward compatibility.
+func (s *Config) GetTargets() ([]checker.Target, error) {
 	s.Lock()
 	defer s.Unlock()
-	f, err := os.Open(s.FileName)
-	if err != nil {
-		return nil, fmt.Errorf("can't open %s: %w", s.FileName, err)
+	if err := s.read(); err != nil {
+		return nil, err
 	}
-	defer f.Close()
-	if err = yaml.NewDecoder(f).Decode(&s.File); err != nil {
-		return nil, fmt.Errorf("can't parse %s: %w", s.FileName, err)
+
+	var targets []checker.Target
+	if s.File.URL != "" {
+		targets = append(targets, checker.Target{
+			Name:      s.File.URL,
+			URL:       s.File.URL,
+			Interval:  s.File.Timeout,
+			Timeout:   s.File.RequestTimeout,
+			MaxAlerts: s.File.MaxAlerts,
+			TLS:       checker.TLS{ExpiryDays: s.File.CertExpiryDays},
+		})
+	}
+
+	names, tokens := map[string]bool{s.File.URL: s.File.URL != ""}, map[string]bool{}
+	for i, t := range s.File.Targets {
+		if t.URL == "" && t.Type != checker.TypeHeartbeat {
+			return nil, fmt.Errorf("target #%d has no url", i+1)
+		}
+		target, err := t.checker(s.File)
+		if err != nil {
+			return nil, err
+		}
+		if names[target.Name] {
+			return nil, fmt.Errorf("target name %q is duplicated", target.Name)
+		}
+		names[target.Name] = true
+		if target.Type == checker.TypeHeartbeat {
+			if s.File.Listen == "" {
+				return nil, fmt.Errorf("target %s is heartbeat, set listen address", target.Name)
+			}
+			if tokens[target.Heartbeat.Token] {
+				return nil, fmt.Errorf("target %s heartbeat token is duplicated", target.Name)
+			}
+			tokens[target.Heartbeat.Token] = true
+		}
+		targets = append(targets, target)
+	}
+
+	for _, t := range targets {
+		if t.Interval <= 0 {
+			return nil, fmt.Errorf("target %s has no interval, set interval or timeout", t.Name)
+		}
+	}
+
+	return targets, nil
+}
+
+func (s *Config) GetProviders(client *http.Client) ([]provider.Interface, error) {
+	s.Lock()
+	defer s.Unlock()
+	if err := s.read(); err != nil {
+		return nil, err
 	}
 	var providers []provider.Interface
 
-	if s.File.Email.Enabled {
+	if s.File.Email.Enabled && s.File.Email.Provider == string(provider.PIDSMTP) {
+		if err := provider.CheckTemplates(s.File.Email.Subject, s.File.Email.Text, s.File.Email.HTML); err != nil {
+			return nil, fmt.Errorf("email template is not valid: %w", err)
+		}
+		smtp := &provider.SMTP{
+			Host:     s.File.Email.SMTP.Host,
+			Port:     s.File.Email.SMTP.Port,
+			Security: s.File.Email.SMTP.Security,
+			Auth:     s.File.Email.SMTP.Auth,
+			Username: s.File.Email.SMTP.Username,
+			Password: s.File.Email.SMTP.Password,
+			From:     s.File.Email.From,
+			To:       s.File.Email.To,
+			Cc:       s.File.Email.Cc,
+			Subject:  s.File.Email.Subject,
+			Text:     s.File.Email.Text,
+			HTML:     s.File.Email.HTML,
+			Provider: provider.Provider{
+				ID:     provider.PIDSMTP,
+				Client: client,
+			},
+		}
+		// the same defaults as command line options
+		if smtp.Port == 0 {
+			smtp.Port = provider.DefaultSMTPPort
+		}
+		if smtp.Security == "" {
+			smtp.Security = provider.SMTPStartTLS
+		}
+		if smtp.Auth == "" {
+			smtp.Auth = provider.SMTPAuthPlain
+		}
+		if err := smtp.Validate(); err != nil {
+			return nil, fmt.Errorf("email smtp is not valid: %w", err)
+		}
+		providers = append(providers, smtp)
+	} else if s.File.Email.Enabled {
+		if err := provider.CheckTemplates(s.File.Email.Subject, s.File.Email.Text); err != nil {
+			return nil, fmt.Errorf("email template is not valid: %w", err)
+		}
 		providers = append(providers, &provider.Mailgun{
-			Values: map[string]io.Reader{
-				"from":    strings.NewReader(s.File.Email.From),
-				"to":      strings.NewReader(s.File.Email.To),
-				"cc":      strings.NewReader(s.File.Email.Cc),
-				"subject": strings.NewReader(s.File.Email.Subject),
-				"text":    strings.NewReader(s.File.Email.Text),
+			Values: map[string]string{
+				"from":    s.File.Email.From,
+				"to":      s.File.Email.To,
+				"cc":      s.File.Email.Cc,
+				"subject": s.File.Email.Subject,
+				"text":    s.File.Email.Text,
 			},
 			Domain: s.File.Email.Mailgun.Domain,
 			APIKey: s.File.Email.Mailgun.APIKey,
@@ -105,6 +273,9 @@ func (s *Config) GetProviders(client *http.Client) ([]provider.Interface, error)
 	}
 
 	if s.File.Telegram.Enabled {
+		if err := provider.CheckTemplates(s.File.Telegram.Message); err != nil {
+			return nil, fmt.Errorf("telegram template is not valid: %w", err)
+		}
 		providers = append(providers, &provider.Telegram{
 			BotAPIKey:   s.File.Telegram.BotAPIKey,
 			ChannelID:   s.File.Telegram.Channel.ID,
@@ -117,5 +288,118 @@ func (s *Config) GetProviders(client *http.Client) ([]provider.Interface, error)
 		})
 	}
 
+	if s.File.Slack.Enabled {
+		if err := provider.CheckTemplates(s.File.Slack.Message); err != nil {
+			return nil, fmt.Errorf("slack template is not valid: %w", err)
+		}
+		providers = append(providers, &provider.Slack{
+			WebhookURL: s.File.Slack.WebhookURL,
+			Message:    s.File.Slack.Message,
+			Provider: provider.Provider{
+				ID:     provider.PIDSlack,
+				Client: client,
+			},
+		})
+	}
+
+	if s.File.Webhook.Enabled {
+		if err := provider.CheckTemplates(s.File.Webhook.Body); err != nil {
+			return nil, fmt.Errorf("webhook template is not valid: %w", err)
+		}
+		providers = append(providers, &provider.Webhook{
+			Method:  s.File.Webhook.Method,
+			URL:     s.File.Webhook.URL,
+			Headers: s.File.Webhook.Headers,
+			Body:    s.File.Webhook.Body,
+			Secret:  s.File.Webhook.Secret,
+			Provider: provider.Provider{
+				ID:     provider.PIDWebhook,
+				Client: client,
+			},
+		})
+	}
+
 	return providers, nil
 }
+
+// GetDigests returns digests with parsed schedules, the title is "Digest" if empty
+func (s *Config) GetDigests() ([]checker.Digest, error) {
+	s.Lock()
+	defer s.Unlock()
+	if err := s.read(); err != nil {
+		return nil, err
+	}
+	var digests []checker.Digest
+	for i, d := range s.File.Digests {
+		schedule, err := checker.ParseSchedule(d.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("digest #%d schedule is not valid: %w", i+1, err)
+		}
+		if d.Title == "" {
+			d.Title = "Digest"
+		}
+		digests = append(digests, checker.Digest{Title: d.Title, Schedule: schedule})
+	}
+	return digests, nil
+}
+
+// GetStatusPage returns the status page with components of known targets, nil if the page is disabled
+func (s *Config) GetStatusPage() (*server.StatusPage, error) {
+	s.Lock()
+	defer s.Unlock()
+	if err := s.read(); err != nil {
+		return nil, err
+	}
+	if !s.File.StatusPage.Enabled {
+		return nil, nil
+	}
+	if s.File.Listen == "" && s.File.StatusPage.Listen == "" {
+		return nil, fmt.Errorf("status page is enabled, set listen address")
+	}
+
+	// targets named by their urls are shown by the neutral name without own component
+	page := &server.StatusPage{Title: s.File.StatusPage.Title, Listen: s.File.StatusPage.Listen}
+	if page.Title == "" {
+		page.Title = "Status"
+	}
+	names := map[string]bool{}
+	if s.File.URL != "" {
+		names[s.File.URL] = true
+		page.Components = append(page.Components, server.Component{Name: urlComponent, Targets: []string{s.File.URL}})
+	}
+	for i, t := range s.File.Targets {
+		component := server.Component{Name: t.Name, Targets: []string{t.Name}}
+		if t.Name == "" {
+			component = server.Component{Name: fmt.Sprintf("Service #%d", i+1), Targets: []string{t.URL}}
+		}
+		names[component.Targets[0]] = true
+		page.Components = append(page.Components, component)
+	}
+	if len(s.File.StatusPage.Components) == 0 {
+		return page, nil
+	}
+
+	page.Components = nil
+	for _, c := range s.File.StatusPage.Components {
+		for _, name := range c.Targets {
+			if !names[name] {
+				return nil, fmt.Errorf("status page component %s has unknown target %q", c.Name, name)
+			}
+		}
+		page.Components = append(page.Components, server.Component{Name: c.Name, Targets: c.Targets})
+	}
+	return page, nil
+}
+
+// read opens and decodes the config file into File
+func (s *Config) read() error {
+	f, err := os.Open(s.FileName)
+	if err != nil {
+		return fmt.Errorf("can't open %s: %w", s.FileName, err)
+	}
+	defer f.Close()
+	if err = yaml.NewDecoder(f).Decode(&s.File); err != nil {
+		return fmt.Errorf("can't parse %s: %w", s.FileName, err)
+	}
+	return nil
+}
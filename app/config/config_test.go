@@ -0,0 +1,271 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/theshamuel/hhchecker/app/checker"
+	"github.com/theshamuel/hhchecker/app/provider"
+	"github.com/theshamuel/hhchecker/app/server"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, text string) *Config {
+	fileName := filepath.Join(t.TempDir(), "hhchecker.yml")
+	if err := os.WriteFile(fileName, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return &Config{FileName: fileName}
+}
+
+func TestGetTargets(t *testing.T) {
+	cnf := writeConfig(t, `
+url: "https://theshamuel.com"
+timeout: "300s"
+request-timeout: "5s"
+cert-expiry-days: 30
+listen: ":8080"
+max-alerts: 3
+targets:
+  - name: "api"
+    url: "https://api.theshamuel.com/health"
+    interval: "10s"
+    timeout: "2s"
+    max-alerts: 1
+    status: "200-299,301"
+    request:
+      method: "POST"
+      headers:
+        X-Probe: "1"
+      basic-auth:
+        username: "user"
+        password: "pass"
+    tls:
+      expiry-days: 7
+      server-name: "api.internal"
+      min-version: "1.2"
+    latency:
+      warning: "1s"
+      critical: "5s"
+      count: 3
+    body:
+      contains: ["ok"]
+    json:
+      - path: "$.status"
+        value: "UP"
+  - url: "https://theshamuel.com/blog"
+  - name: "redis"
+    type: "tcp"
+    url: "redis:6379"
+    tcp:
+      send: "PING\r\n"
+      expect: "+PONG"
+  - name: "mx"
+    type: "dns"
+    url: "theshamuel.com"
+    dns:
+      record: "MX"
+      resolver: "1.1.1.1"
+      equals: ["mx1.theshamuel.com", "mx2.theshamuel.com"]
+  - name: "billing"
+    type: "grpc"
+    url: "billing.internal:9090"
+    grpc:
+      service: "billing.v1.Billing"
+  - name: "gateway"
+    type: "websocket"
+    url: "wss://rt.theshamuel.com/ws"
+    websocket:
+      send: '{"type":"ping"}'
+      expect: '"pong"'
+  - name: "backup"
+    type: "heartbeat"
+    interval: "1m"
+    heartbeat:
+      token: "b4ck"
+      period: "24h"
+      grace: "1h"
+`)
+	targets, err := cnf.GetTargets()
+	assert.NoError(t, err)
+	assert.Len(t, targets, 8)
+
+	assert.Equal(t, "https://theshamuel.com", targets[0].Name)
+	assert.Equal(t, 300*time.Second, targets[0].Interval)
+
+	api := targets[1]
+	assert.Equal(t, "api", api.Name)
+	assert.Equal(t, 10*time.Second, api.Interval)
+	assert.Equal(t, 2*time.Second, api.Timeout)
+	assert.Equal(t, checker.TLS{ExpiryDays: 7, ServerName: "api.internal", MinVersion: "1.2"}, api.TLS)
+	assert.Equal(t, int8(1), api.MaxAlerts)
+	assert.Equal(t, checker.StatusCodes{{200, 299}, {301, 301}}, api.Status)
+	assert.Equal(t, "POST", api.Request.Method)
+	assert.Equal(t, "user", api.Request.Username)
+	assert.Equal(t, []string{"ok"}, api.Body.Contains)
+	assert.Equal(t, checker.LatencyThreshold{Warning: time.Second, Critical: 5 * time.Second, Count: 3}, api.Latency)
+	assert.Equal(t, []checker.JSONAssertion{{Path: "$.status", Value: "UP"}}, api.JSON)
+
+	blog := targets[2]
+	assert.Equal(t, "https://theshamuel.com/blog", blog.Name)
+	assert.Equal(t, 300*time.Second, blog.Interval)
+	assert.Equal(t, 5*time.Second, blog.Timeout)
+	assert.Equal(t, 30, blog.TLS.ExpiryDays)
+	assert.Equal(t, int8(3), blog.MaxAlerts)
+
+	redis := targets[3]
+	assert.Equal(t, checker.TypeTCP, redis.Type)
+	assert.Equal(t, "redis:6379", redis.URL)
+	assert.Equal(t, checker.TCPCheck{Send: "PING\r\n", Expect: "+PONG"}, redis.TCP)
+
+	mx := targets[4]
+	assert.Equal(t, checker.TypeDNS, mx.Type)
+	assert.Equal(t, checker.DNSCheck{Record: "MX", Resolver: "1.1.1.1",
+		Equals: []string{"mx1.theshamuel.com", "mx2.theshamuel.com"}}, mx.DNS)
+	assert.Equal(t, checker.GRPCCheck{Service: "billing.v1.Billing"}, targets[5].GRPC)
+	assert.Equal(t, checker.WebSocketCheck{Send: `{"type":"ping"}`, Expect: `"pong"`}, targets[6].WebSocket)
+	assert.Equal(t, checker.HeartbeatCheck{Token: "b4ck", Period: 24 * time.Hour, Grace: time.Hour}, targets[7].Heartbeat)
+	assert.Equal(t, time.Minute, targets[7].Interval)
+}
+
+func TestGetTargetsErrors(t *testing.T) {
+	tbl := []struct {
+		config string
+		err    string
+	}{
+		{"targets:\n  - name: a\n", "target #1 has no url"},
+		{"timeout: 1s\ntargets:\n  - {name: a, url: http://a}\n  - {name: a, url: http://b}\n", `target name "a" is duplicated`},
+		{"targets:\n  - {name: a, url: http://a}\n", "target a has no interval, set interval or timeout"},
+		{"url: http://a\ntimeout: 1s\ntargets:\n  - {name: http://a, url: http://b}\n", `target name "http://a" is duplicated`},
+		{"timeout: 1s\ntargets:\n  - {name: a, url: http://a, status: abc}\n", `target a status is not valid: status code "abc" is not valid`},
+		{"timeout: 1s\ntargets:\n  - {name: a, type: udp, url: a:53}\n", `target a type "udp" is not supported`},
+		{"timeout: 1s\ntargets:\n  - {name: a, type: grpc, url: a}\n",
+			`target a grpc is not valid: address "a" is not host:port: address a: missing port in address`},
+		{"timeout: 1s\ntargets:\n  - {name: a, type: heartbeat, heartbeat: {token: t, period: 1h}}\n",
+			"target a is heartbeat, set listen address"},
+		{"timeout: 1s\ntargets:\n  - {name: a, type: heartbeat, heartbeat: {token: t}}\n",
+			"target a heartbeat requires token and period"},
+		{"timeout: 1s\nlisten: :8080\ntargets:\n  - {name: a, type: heartbeat, heartbeat: {token: t, period: 1h}}\n" +
+			"  - {name: b, type: heartbeat, heartbeat: {token: t, period: 1h}}\n",
+			"target b heartbeat token is duplicated"},
+		{"timeout: 1s\ntargets:\n  - {name: a, type: dns, url: a, dns: {record: PTR}}\n",
+			`target a dns is not valid: dns record "PTR" is not one of A, AAAA, CNAME, MX, TXT`},
+		{"timeout: 1s\ntargets:\n  - {name: a, type: tcp, url: postgres}\n",
+			`target a url is not valid: address "postgres" is not host:port: address postgres: missing port in address`},
+		{"timeout: 1s\ntargets:\n  - {name: a, url: http://a, tls: {cert-file: a.pem}}\n",
+			"target a tls is not valid: both cert file and key file are required for client certificate"},
+	}
+	for _, tt := range tbl {
+		_, err := writeConfig(t, tt.config).GetTargets()
+		assert.EqualError(t, err, tt.err)
+	}
+}
+
+func TestGetStatusPage(t *testing.T) {
+	cnf := writeConfig(t, `
+listen: ":8080"
+targets:
+  - name: "web"
+    url: "https://theshamuel.com"
+  - url: "https://api.theshamuel.com"
+status-page:
+  enabled: true
+  components:
+    - name: "Website"
+      targets: ["web", "https://api.theshamuel.com"]
+`)
+	page, err := cnf.GetStatusPage()
+	assert.NoError(t, err)
+	assert.Equal(t, &server.StatusPage{Title: "Status", Components: []server.Component{
+		{Name: "Website", Targets: []string{"web", "https://api.theshamuel.com"}},
+	}}, page)
+
+	page, err = writeConfig(t, `
+url: "https://theshamuel.com"
+targets:
+  - name: "web"
+    url: "https://theshamuel.com/blog"
+  - url: "https://api.theshamuel.com"
+status-page:
+  enabled: true
+  listen: ":8081"
+`).GetStatusPage()
+	assert.NoError(t, err)
+	assert.Equal(t, &server.StatusPage{Title: "Status", Listen: ":8081", Components: []server.Component{
+		{Name: "Service", Targets: []string{"https://theshamuel.com"}},
+		{Name: "web", Targets: []string{"web"}},
+		{Name: "Service #2", Targets: []string{"https://api.theshamuel.com"}},
+	}}, page, "urls are not component names")
+
+	page, err = writeConfig(t, "listen: \":8080\"\n").GetStatusPage()
+	assert.NoError(t, err)
+	assert.Nil(t, page, "disabled by default")
+
+	_, err = writeConfig(t, "status-page: {enabled: true}\n").GetStatusPage()
+	assert.EqualError(t, err, "status page is enabled, set listen address")
+
+	_, err = writeConfig(t, "listen: :8080\nstatus-page: {enabled: true, components: [{name: Website, targets: [web]}]}\n").GetStatusPage()
+	assert.EqualError(t, err, `status page component Website has unknown target "web"`)
+}
+
+func TestGetDigests(t *testing.T) {
+	cnf := writeConfig(t, `
+digests:
+  - title: "Daily digest"
+    schedule: "0 9 * * *"
+  - schedule: "@weekly"
+`)
+	digests, err := cnf.GetDigests()
+	assert.NoError(t, err)
+	assert.Len(t, digests, 2)
+	assert.Equal(t, "Daily digest", digests[0].Title)
+	assert.Equal(t, "0 9 * * *", digests[0].Schedule.Expr)
+	assert.Equal(t, "Digest", digests[1].Title)
+
+	_, err = writeConfig(t, "digests:\n  - schedule: \"0 9 * *\"\n").GetDigests()
+	assert.EqualError(t, err, `digest #1 schedule is not valid: cron expression "0 9 * *" has 4 fields instead of 5`)
+}
+
+func TestGetProvidersSMTP(t *testing.T) {
+	cnf := writeConfig(t, `
+email:
+  enabled: true
+  provider: "smtp"
+  smtp:
+    host: "mail.theshamuel.com"
+`)
+	providers, err := cnf.GetProviders(http.DefaultClient)
+	assert.NoError(t, err)
+	assert.Len(t, providers, 1)
+	smtp, ok := providers[0].(*provider.SMTP)
+	assert.True(t, ok)
+	assert.Equal(t, provider.DefaultSMTPPort, smtp.Port)
+	assert.Equal(t, provider.SMTPStartTLS, smtp.Security)
+	assert.Equal(t, provider.SMTPAuthPlain, smtp.Auth)
+
+	tbl := []struct {
+		smtp, err string
+	}{
+		{"{host: h, security: startls}", `email smtp is not valid: smtp security "startls" is not one of plain, starttls, tls`},
+		{"{host: h, auth: cram}", `email smtp is not valid: smtp auth "cram" is not one of plain, login`},
+	}
+	for _, tt := range tbl {
+		_, err = writeConfig(t, "email:\n  enabled: true\n  provider: smtp\n  smtp: "+tt.smtp+"\n").GetProviders(http.DefaultClient)
+		assert.EqualError(t, err, tt.err, tt.smtp)
+	}
+}
+
+func TestExampleConfig(t *testing.T) {
+	cnf := &Config{FileName: "../../hhchecker-example.yml"}
+	targets, err := cnf.GetTargets()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, targets)
+	_, err = cnf.GetProviders(http.DefaultClient)
+	assert.NoError(t, err)
+	_, err = cnf.GetDigests()
+	assert.NoError(t, err)
+	_, err = cnf.GetStatusPage()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"fmt"
+	"github.com/theshamuel/hhchecker/app/checker"
+	"log"
+	"regexp"
+	"time"
+)
+
+// Target is the config section of a single monitored endpoint.
+// Interval, timeout and max-alerts fall back to the top level timeout, request-timeout and max-alerts when omitted.
+type Target struct {
+	Name      string        `yaml:"name"`
+	Type      string        `yaml:"type,omitempty"`
+	URL       string        `yaml:"url"`
+	Interval  time.Duration `yaml:"interval,omitempty"`
+	Timeout   time.Duration `yaml:"timeout,omitempty"`
+	MaxAlerts int8          `yaml:"max-alerts,omitempty"`
+	Status    string        `yaml:"status,omitempty"`
+	Request   struct {
+		Method    string            `yaml:"method,omitempty"`
+		Headers   map[string]string `yaml:"headers,omitempty"`
+		Body      string            `yaml:"body,omitempty"`
+		BasicAuth struct {
+			Username string `yaml:"username,omitempty"`
+			Password string `yaml:"password,omitempty"`
+		} `yaml:"basic-auth,omitempty"`
+		BearerToken string `yaml:"bearer-token,omitempty"`
+	} `yaml:"request,omitempty"`
+	Body struct {
+		MaxSize     int64    `yaml:"max-size,omitempty"`
+		Contains    []string `yaml:"contains,omitempty"`
+		NotContains []string `yaml:"not-contains,omitempty"`
+		Regex       []string `yaml:"regex,omitempty"`
+	} `yaml:"body,omitempty"`
+	Latency struct {
+		Warning  time.Duration `yaml:"warning,omitempty"`
+		Critical time.Duration `yaml:"critical,omitempty"`
+		Count    int           `yaml:"count,omitempty"`
+	} `yaml:"latency,omitempty"`
+	TLS struct {
+		ExpiryDays         int    `yaml:"expiry-days,omitempty"`
+		CAFile             string `yaml:"ca-file,omitempty"`
+		CertFile           string `yaml:"cert-file,omitempty"`
+		KeyFile            string `yaml:"key-file,omitempty"`
+		ServerName         string `yaml:"server-name,omitempty"`
+		MinVersion         string `yaml:"min-version,omitempty"`
+		InsecureSkipVerify bool   `yaml:"insecure-skip-verify,omitempty"`
+	} `yaml:"tls,omitempty"`
+	TCP struct {
+		Send   string `yaml:"send,omitempty"`
+		Expect string `yaml:"expect,omitempty"`
+	} `yaml:"tcp,omitempty"`
+	DNS struct {
+		Record        string   `yaml:"record,omitempty"`
+		Resolver      string   `yaml:"resolver,omitempty"`
+		Contains      []string `yaml:"contains,omitempty"`
+		Equals        []string `yaml:"equals,omitempty"`
+		AlertOnChange bool     `yaml:"alert-on-change,omitempty"`
+	} `yaml:"dns,omitempty"`
+	GRPC struct {
+		Service   string `yaml:"service,omitempty"`
+		Plaintext bool   `yaml:"plaintext,omitempty"`
+	} `yaml:"grpc,omitempty"`
+	WebSocket struct {
+		Send   string `yaml:"send,omitempty"`
+		Expect string `yaml:"expect,omitempty"`
+	} `yaml:"websocket,omitempty"`
+	Heartbeat struct {
+		Token  string        `yaml:"token,omitempty"`
+		Period time.Duration `yaml:"period,omitempty"`
+		Grace  time.Duration `yaml:"grace,omitempty"`
+	} `yaml:"heartbeat,omitempty"`
+	JSON []struct {
+		Path  string      `yaml:"path"`
+		Op    string      `yaml:"op,omitempty"`
+		Value interface{} `yaml:"value,omitempty"`
+	} `yaml:"json,omitempty"`
+}
+
+// checker converts the config section into checker target with the top level defaults of the file
+func (t Target) checker(f *File) (checker.Target, error) {
+	target := checker.Target{
+		Name:      t.Name,
+		Type:      t.Type,
+		URL:       t.URL,
+		Interval:  t.Interval,
+		Timeout:   t.Timeout,
+		MaxAlerts: t.MaxAlerts,
+		Request: checker.Request{
+			Method:      t.Request.Method,
+			Headers:     t.Request.Headers,
+			Body:        t.Request.Body,
+			Username:    t.Request.BasicAuth.Username,
+			Password:    t.Request.BasicAuth.Password,
+			BearerToken: t.Request.BearerToken,
+		},
+		Body: checker.BodyAssertion{
+			Contains:    t.Body.Contains,
+			NotContains: t.Body.NotContains,
+			MaxSize:     t.Body.MaxSize,
+		},
+		Latency: checker.LatencyThreshold{
+			Warning:  t.Latency.Warning,
+			Critical: t.Latency.Critical,
+			Count:    t.Latency.Count,
+		},
+		TLS: checker.TLS{
+			ExpiryDays:         t.TLS.ExpiryDays,
+			CAFile:             t.TLS.CAFile,
+			CertFile:           t.TLS.CertFile,
+			KeyFile:            t.TLS.KeyFile,
+			ServerName:         t.TLS.ServerName,
+			MinVersion:         t.TLS.MinVersion,
+			InsecureSkipVerify: t.TLS.InsecureSkipVerify,
+		},
+		TCP: checker.TCPCheck{Send: t.TCP.Send, Expect: t.TCP.Expect},
+		DNS: checker.DNSCheck{
+			Record:        t.DNS.Record,
+			Resolver:      t.DNS.Resolver,
+			Contains:      t.DNS.Contains,
+			Equals:        t.DNS.Equals,
+			AlertOnChange: t.DNS.AlertOnChange,
+		},
+		GRPC:      checker.GRPCCheck{Service: t.GRPC.Service, Plaintext: t.GRPC.Plaintext},
+		WebSocket: checker.WebSocketCheck{Send: t.WebSocket.Send, Expect: t.WebSocket.Expect},
+		Heartbeat: checker.HeartbeatCheck{Token: t.Heartbeat.Token, Period: t.Heartbeat.Period, Grace: t.Heartbeat.Grace},
+	}
+	if target.Name == "" {
+		target.Name = t.URL
+	}
+	if target.Name == "" {
+		return target, fmt.Errorf("heartbeat target has no name")
+	}
+	if target.Interval == 0 {
+		target.Interval = f.Timeout
+	}
+	if target.Timeout == 0 {
+		target.Timeout = f.RequestTimeout
+	}
+	if target.MaxAlerts == 0 {
+		target.MaxAlerts = f.MaxAlerts
+	}
+	if target.TLS.ExpiryDays == 0 {
+		target.TLS.ExpiryDays = f.CertExpiryDays
+	}
+
+	var err error
+	switch target.Type {
+	case "", checker.TypeHTTP, checker.TypeWebSocket:
+	case checker.TypeTCP:
+		if _, err = checker.Address(target.URL); err != nil {
+			return target, fmt.Errorf("target %s url is not valid: %w", target.Name, err)
+		}
+	case checker.TypeDNS:
+		if err = target.DNS.Validate(); err != nil {
+			return target, fmt.Errorf("target %s dns is not valid: %w", target.Name, err)
+		}
+	case checker.TypeGRPC:
+		if err = target.GRPC.Validate(target.URL); err != nil {
+			return target, fmt.Errorf("target %s grpc is not valid: %w", target.Name, err)
+		}
+	case checker.TypeHeartbeat:
+		if target.Heartbeat.Token == "" || target.Heartbeat.Period <= 0 {
+			return target, fmt.Errorf("target %s heartbeat requires token and period", target.Name)
+		}
+	default:
+		return target, fmt.Errorf("target %s type %q is not supported", target.Name, target.Type)
+	}
+	if target.Status, err = checker.ParseStatusCodes(t.Status); err != nil {
+		return target, fmt.Errorf("target %s status is not valid: %w", target.Name, err)
+	}
+	if _, err = target.TLS.Config(nil); err != nil {
+		return target, fmt.Errorf("target %s tls is not valid: %w", target.Name, err)
+	}
+	if target.TLS.InsecureSkipVerify {
+		log.Printf("[WARN] target %s certificate chain and hostname are not verified", target.Name)
+	}
+	for _, expr := range t.Body.Regex {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return target, fmt.Errorf("target %s body regex is not valid: %w", target.Name, err)
+		}
+		target.Body.Regex = append(target.Body.Regex, re)
+	}
+	for _, j := range t.JSON {
+		assertion := checker.JSONAssertion{Path: j.Path, Op: j.Op, Value: j.Value}
+		if err := assertion.Validate(); err != nil {
+			return target, fmt.Errorf("target %s json assertion is not valid: %w", target.Name, err)
+		}
+		target.JSON = append(target.JSON, assertion)
+	}
+	return target, nil
+}
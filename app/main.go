@@ -1,18 +1,21 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/hashicorp/logutils"
 	"github.com/theshamuel/go-flags"
+	"github.com/theshamuel/hhchecker/app/checker"
 	"github.com/theshamuel/hhchecker/app/config"
 	"github.com/theshamuel/hhchecker/app/provider"
+	"github.com/theshamuel/hhchecker/app/server"
+	"github.com/theshamuel/hhchecker/app/store"
 	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"runtime"
-	"strings"
 	"syscall"
 	"time"
 )
@@ -20,14 +23,22 @@ import (
 var opts struct {
 	config.CommonOpts
 	Email struct {
-		Enabled       bool   `long:"enabled" env:"ENABLED" description:"enable email mailgun provider"`
+		Enabled       bool   `long:"enabled" env:"ENABLED" description:"enable email provider"`
+		Provider      string `long:"provider" env:"PROVIDER" default:"mailgun" choice:"mailgun" choice:"smtp" description:"the email provider"`
 		From          string `long:"from" env:"FROM" description:"the source email address"`
-		To            string `long:"to" env:"TO" description:"the target email address"`
-		Cc            string `long:"cc" env:"CC" description:"the cc email address"`
-		Subject       string `long:"subject" env:"SUBJECT" description:"the subject of email"`
-		Text          string `long:"text" env:"TEXT" description:"the text of email not more 255 letters"`
+		To            string `long:"to" env:"TO" description:"the target email address, comma separated list for smtp"`
+		Cc            string `long:"cc" env:"CC" description:"the cc email address, comma separated list for smtp"`
+		Subject       string `long:"subject" env:"SUBJECT" description:"the subject of email, go text/template with alert context"`
+		Text          string `long:"text" env:"TEXT" description:"the text of email not more 255 letters, go text/template with alert context"`
+		HTML          string `long:"html" env:"HTML" description:"the html of email for smtp, go html/template with alert context"`
 		Domain        string `long:"domain" env:"DOMAIN" description:"the mailgun API URL for sending notification"`
 		MailgunAPIKey string `long:"mailgunApiKey" env:"MAILGUN_API_KEY" description:"the token for mailgun api"`
+		SMTPHost      string `long:"smtpHost" env:"SMTP_HOST" description:"the smtp server host"`
+		SMTPPort      int    `long:"smtpPort" env:"SMTP_PORT" default:"587" description:"the smtp server port"`
+		SMTPSecurity  string `long:"smtpSecurity" env:"SMTP_SECURITY" default:"starttls" choice:"plain" choice:"starttls" choice:"tls" description:"the smtp connection security"`
+		SMTPAuth      string `long:"smtpAuth" env:"SMTP_AUTH" default:"plain" choice:"plain" choice:"login" description:"the smtp auth mechanism"`
+		SMTPUsername  string `long:"smtpUsername" env:"SMTP_USERNAME" description:"the smtp username, auth is skipped if empty"`
+		SMTPPassword  string `long:"smtpPassword" env:"SMTP_PASSWORD" description:"the smtp password"`
 	} `group:"email" namespace:"email" env-namespace:"EMAIL"`
 
 	Telegram struct {
@@ -35,19 +46,42 @@ var opts struct {
 		BotAPIKey   string `long:"botApiKey" env:"BOT_API_KEY" description:"the telegram bot api key"`
 		ChannelName string `long:"channelName" env:"CHANNEL_NAME" description:"the channel name without leading symbol @ for public channel only"`
 		ChannelID   string `long:"channelId" env:"CHANNEL_ID" description:"the channel id for private channel only"`
-		Message     string `long:"message" env:"MESSAGE" description:"the text message not more 255 letters"`
+		Message     string `long:"message" env:"MESSAGE" description:"the text message not more 255 letters, go text/template with alert context"`
 	} `group:"telegram" namespace:"telegram" env-namespace:"TELEGRAM"`
 
+	Slack struct {
+		Enabled    bool   `long:"enabled" env:"ENABLED" description:"enable slack provider"`
+		WebhookURL string `long:"webhookUrl" env:"WEBHOOK_URL" description:"the slack incoming webhook url"`
+		Message    string `long:"message" env:"MESSAGE" description:"the text message, go text/template with alert context"`
+	} `group:"slack" namespace:"slack" env-namespace:"SLACK"`
+
+	Webhook struct {
+		Enabled bool              `long:"enabled" env:"ENABLED" description:"enable outgoing webhook provider"`
+		Method  string            `long:"method" env:"METHOD" default:"POST" description:"the http method of webhook"`
+		URL     string            `long:"url" env:"URL" description:"the webhook url"`
+		Headers map[string]string `long:"header" env:"HEADERS" env-delim:"," description:"the http header of webhook in form name:value, can be repeated"`
+		Body    string            `long:"body" env:"BODY" description:"the JSON body of webhook, go text/template with alert context"`
+		Secret  string            `long:"secret" env:"SECRET" description:"the secret for HMAC-SHA256 signature of body in X-Hhchecker-Signature header"`
+	} `group:"webhook" namespace:"webhook" env-namespace:"WEBHOOK"`
+
 	Config struct {
 		Enabled  bool   `long:"enabled" env:"ENABLED" description:"enable getting parameters from config. In that case all parameters will be read only form config"`
 		FileName string `long:"file-name" env:"FILE_NAME" default:"hhchecker.yml" description:"config file name"`
 	} `group:"config" namespace:"config" env-namespace:"CONFIG"`
+
+	Report struct {
+		From    string   `long:"from" description:"the start of period, YYYY-MM-DD or RFC3339 time, 30 days before the end by default"`
+		To      string   `long:"to" description:"the end of period, YYYY-MM-DD or RFC3339 time, now by default"`
+		Format  string   `long:"format" default:"text" choice:"text" choice:"csv" choice:"json" description:"the report format"`
+		Targets []string `long:"target" description:"the target name, all targets of the store if empty, can be repeated"`
+	} `command:"report" description:"print uptime, incidents, MTTR and longest outage of every target from the history store and exit"`
 }
 
 var version = "unknown"
 
 func main() {
-	parseFlags()
+	startedAt := time.Now()
+	command := parseFlags()
 
 	var cnf *config.Config
 	if opts.Config.Enabled {
@@ -57,17 +91,41 @@ func main() {
 	}
 
 	var client = &http.Client{Timeout: 3 * time.Second}
-	var maxAlerts = int8(0)
 	var providers []provider.Interface
 
-	if opts.Email.Enabled {
+	if opts.Email.Enabled && opts.Email.Provider == string(provider.PIDSMTP) {
+		if err := provider.CheckTemplates(opts.Email.Subject, opts.Email.Text, opts.Email.HTML); err != nil {
+			panic(fmt.Errorf("[ERROR] email template is not valid, %w", err))
+		}
+		providers = append(providers, &provider.SMTP{
+			Host:     opts.Email.SMTPHost,
+			Port:     opts.Email.SMTPPort,
+			Security: opts.Email.SMTPSecurity,
+			Auth:     opts.Email.SMTPAuth,
+			Username: opts.Email.SMTPUsername,
+			Password: opts.Email.SMTPPassword,
+			From:     opts.Email.From,
+			To:       opts.Email.To,
+			Cc:       opts.Email.Cc,
+			Subject:  opts.Email.Subject,
+			Text:     opts.Email.Text,
+			HTML:     opts.Email.HTML,
+			Provider: provider.Provider{
+				ID:     provider.PIDSMTP,
+				Client: client,
+			},
+		})
+	} else if opts.Email.Enabled {
+		if err := provider.CheckTemplates(opts.Email.Subject, opts.Email.Text); err != nil {
+			panic(fmt.Errorf("[ERROR] email template is not valid, %w", err))
+		}
 		providers = append(providers, &provider.Mailgun{
-			Values: map[string]io.Reader{
-				"from":    strings.NewReader(opts.Email.From),
-				"to":      strings.NewReader(opts.Email.To),
-				"cc":      strings.NewReader(opts.Email.Cc),
-				"subject": strings.NewReader(opts.Email.Subject),
-				"text":    strings.NewReader(opts.Email.Text),
+			Values: map[string]string{
+				"from":    opts.Email.From,
+				"to":      opts.Email.To,
+				"cc":      opts.Email.Cc,
+				"subject": opts.Email.Subject,
+				"text":    opts.Email.Text,
 			},
 			Domain: opts.Email.Domain,
 			APIKey: opts.Email.MailgunAPIKey,
@@ -79,6 +137,9 @@ func main() {
 	}
 
 	if opts.Telegram.Enabled {
+		if err := provider.CheckTemplates(opts.Telegram.Message); err != nil {
+			panic(fmt.Errorf("[ERROR] telegram template is not valid, %w", err))
+		}
 		providers = append(providers, &provider.Telegram{
 			BotAPIKey:   opts.Telegram.BotAPIKey,
 			ChannelID:   opts.Telegram.ChannelID,
@@ -91,6 +152,48 @@ func main() {
 		})
 	}
 
+	if opts.Slack.Enabled {
+		if err := provider.CheckTemplates(opts.Slack.Message); err != nil {
+			panic(fmt.Errorf("[ERROR] slack template is not valid, %w", err))
+		}
+		providers = append(providers, &provider.Slack{
+			WebhookURL: opts.Slack.WebhookURL,
+			Message:    opts.Slack.Message,
+			Provider: provider.Provider{
+				ID:     provider.PIDSlack,
+				Client: client,
+			},
+		})
+	}
+
+	if opts.Webhook.Enabled {
+		if err := provider.CheckTemplates(opts.Webhook.Body); err != nil {
+			panic(fmt.Errorf("[ERROR] webhook template is not valid, %w", err))
+		}
+		providers = append(providers, &provider.Webhook{
+			Method:  opts.Webhook.Method,
+			URL:     opts.Webhook.URL,
+			Headers: opts.Webhook.Headers,
+			Body:    opts.Webhook.Body,
+			Secret:  opts.Webhook.Secret,
+			Provider: provider.Provider{
+				ID:     provider.PIDWebhook,
+				Client: client,
+			},
+		})
+	}
+
+	targets := []checker.Target{opts.Target()}
+	statusPage := opts.GetStatusPage()
+	var digests []checker.Digest
+	for _, expr := range opts.Digests {
+		schedule, err := checker.ParseSchedule(expr)
+		if err != nil {
+			panic(fmt.Errorf("[ERROR] digest schedule is not valid, %w", err))
+		}
+		digests = append(digests, checker.Digest{Title: "Digest", Schedule: schedule})
+	}
+
 	if opts.Config.Enabled {
 		var err error
 		var co *config.CommonOpts
@@ -101,48 +204,140 @@ func main() {
 		opts.URL = co.URL
 		opts.Debug = co.Debug
 		opts.Timeout = co.Timeout
+		opts.RequestTimeout = co.RequestTimeout
+		opts.CertExpiryDays = co.CertExpiryDays
 		opts.MaxAlerts = co.MaxAlerts
+		opts.Listen = co.Listen
+		opts.Store = co.Store
+		opts.Retention = co.Retention
+		opts.RawRetention = co.RawRetention
 		log.Printf("[DEBUG] config: %+v", cnf.File)
 
 		if providers, err = cnf.GetProviders(client); err != nil {
 			panic(fmt.Errorf("[ERROR] can not read config file, %w", err))
 		}
+
+		if targets, err = cnf.GetTargets(); err != nil {
+			panic(fmt.Errorf("[ERROR] can not read config file, %w", err))
+		}
+
+		if statusPage, err = cnf.GetStatusPage(); err != nil {
+			panic(fmt.Errorf("[ERROR] can not read config file, %w", err))
+		}
+
+		if digests, err = cnf.GetDigests(); err != nil {
+			panic(fmt.Errorf("[ERROR] can not read config file, %w", err))
+		}
 	}
 
 	setupLogLevel(opts.Debug)
 
+	if command == "report" {
+		if err := report(os.Stdout, time.Now()); err != nil {
+			log.Printf("[ERROR] can't make report: %v", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	log.Printf("[DEBUG] options: %+v", opts)
 	log.Printf("[DEBUG] providers: %+v", providers)
 
-	log.Printf("[INFO] Starting Health checker for %s:[version: %s] ...\n", opts.URL, version)
-
-	for range time.Tick(opts.Timeout) {
-		response, err := http.Get(opts.URL)
-		log.Printf("[DEBUG] Get response: %+v", response)
-		if err != nil || response.StatusCode != 200 {
-			if maxAlerts >= opts.MaxAlerts {
-				for _, provider := range providers {
-					if err = provider.Send(); err != nil {
-						log.Printf("[ERROR] error occurs during sending [%s] message: %+v", provider.GetID(), err)
-					}
-				}
-				maxAlerts = 0
+	if len(targets) == 0 || (!opts.Config.Enabled && opts.URL == "") {
+		log.Printf("[ERROR] no targets to healthcheck, set url or targets in config")
+		os.Exit(1)
+	}
+
+	if statusPage != nil && opts.Listen == "" && statusPage.Listen == "" {
+		log.Printf("[ERROR] status page requires listen address")
+		os.Exit(1)
+	}
+
+	log.Printf("[INFO] Starting Health checker for %d target(s):[version: %s] ...\n", len(targets), version)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	scheduler := &checker.Scheduler{
+		Targets:       targets,
+		Providers:     providers,
+		Client:        &http.Client{},
+		NotifyTimeout: 30 * time.Second,
+		Digests:       digests,
+	}
+	if opts.Store != "" {
+		st, err := store.Open(opts.Store, opts.Retention, opts.RawRetention)
+		if err != nil {
+			log.Printf("[ERROR] can't open history store: %v", err)
+			os.Exit(1)
+		}
+		defer st.Close()
+		scheduler.History = st
+	}
+	if opts.Listen != "" || (statusPage != nil && statusPage.Listen != "") {
+		srv := &server.Server{Address: opts.Listen, Scheduler: scheduler, Version: version, StartedAt: startedAt,
+			StatusPage: statusPage}
+		go func() {
+			if err := srv.Run(ctx); err != nil {
+				log.Printf("[ERROR] http server failed: %v", err)
+				cancel()
 			}
-			maxAlerts++
-		} else if response != nil && response.StatusCode == 200 {
-			maxAlerts = 0
+		}()
+	}
+	scheduler.Run(ctx)
+	log.Printf("[INFO] Health checker is stopped")
+}
+
+// report writes the uptime report of the store targets for the period of report options
+func report(w io.Writer, now time.Time) error {
+	if opts.Store == "" {
+		return fmt.Errorf("history store is not set")
+	}
+	from, to, err := checker.ParsePeriod(opts.Report.From, opts.Report.To, now)
+	if err != nil {
+		return err
+	}
+	st, err := store.Read(opts.Store)
+	if err != nil {
+		return err
+	}
+	names, known := opts.Report.Targets, map[string]bool{}
+	for _, name := range st.Targets() {
+		known[name] = true
+		if len(opts.Report.Targets) == 0 {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		if !known[name] {
+			return fmt.Errorf("target %q is not found in history store %s", name, opts.Store)
 		}
 	}
+	reports := make([]checker.Report, 0, len(names))
+	for _, name := range names {
+		days, incidents, err := st.Load(name)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, checker.NewReport(name, days, incidents, from, to))
+	}
+	return checker.WriteReports(w, opts.Report.Format, from, to, reports)
 }
 
-func parseFlags() {
+// parseFlags parses options and returns the name of the command, empty to run checker
+func parseFlags() string {
 	p := flags.NewParser(&opts, flags.Default)
+	p.SubcommandsOptional = true
 	if _, err := p.Parse(); err != nil {
 		if flagsErr, ok := err.(*flags.Error); ok && flagsErr.Type == flags.ErrHelp {
 			os.Exit(0)
 		}
 		os.Exit(1)
 	}
+	if p.Active == nil {
+		return ""
+	}
+	return p.Active.Name
 }
 
 func setupLogLevel(debug bool) {
@@ -171,7 +366,7 @@ func getStackTrace() string {
 }
 
 func init() {
-	sigChan := make(chan os.Signal)
+	sigChan := make(chan os.Signal, 1)
 	go func() {
 		for range sigChan {
 			log.Printf("[INFO] Singal QUITE is cought , stacktrace [\n%s", getStackTrace())
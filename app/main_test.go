@@ -3,11 +3,15 @@ package main
 import (
 	"bytes"
 	"github.com/stretchr/testify/assert"
+	"github.com/theshamuel/hhchecker/app/checker"
+	"github.com/theshamuel/hhchecker/app/store"
 	"go.uber.org/goleak"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestMain(m *testing.M)  {
@@ -24,6 +28,26 @@ func TestGetStackTrace(t *testing.T) {
 	t.Logf("\n STACKTRACE: %s", stackTrace)
 }
 
+func TestReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	st, err := store.Open(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	assert.NoError(t, st.Record("api", checker.Result{StatusCode: 200, Time: now}))
+	assert.NoError(t, st.Close())
+	defer func() { opts.Store, opts.Report.Targets, opts.Report.Format = "", nil, "" }()
+	opts.Store, opts.Report.Format = path, checker.ReportCSV
+
+	var buf bytes.Buffer
+	assert.NoError(t, report(&buf, now))
+	assert.Contains(t, buf.String(), "\napi,")
+
+	opts.Report.Targets = []string{"api", "web"}
+	assert.EqualError(t, report(&buf, now), `target "web" is not found in history store `+path)
+}
+
 func captureStdout(f func()) string {
 	var buf bytes.Buffer
 	log.SetOutput(&buf)
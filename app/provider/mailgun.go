@@ -2,6 +2,7 @@ package provider
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -14,29 +15,33 @@ import (
 type Mailgun struct {
 	Domain   string
 	APIKey   string
-	Values   map[string]io.Reader
+	Values   map[string]string
 	Provider Provider
 }
 
 // Send sending email via MailGun
-func (s *Mailgun) Send() (err error) {
+func (s *Mailgun) Send(ctx context.Context, a Alert) (err error) {
 	url := fmt.Sprintf("https://api.mailgun.net/v3/%s/messages", s.Domain)
 	var b bytes.Buffer
 	w := multipart.NewWriter(&b)
-	for key, r := range s.Values {
-		var fw io.Writer
-		if fw, err = w.CreateFormField(key); err != nil {
+	for key, value := range s.Values {
+		switch key {
+		case "subject":
+			value, err = a.Render(value, DefaultSubjectTemplate)
+		case "text":
+			value, err = a.Render(value, DefaultTextTemplate)
+		}
+		if err != nil {
 			return err
 		}
-		if _, err = io.Copy(fw, r); err != nil {
+		if err = w.WriteField(key, value); err != nil {
 			return err
 		}
-
 	}
 	if err := w.Close(); err != nil {
 		return err
 	}
-	req, err := http.NewRequest("POST", url, &b)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &b)
 	if err != nil {
 		return err
 	}
@@ -50,11 +55,13 @@ func (s *Mailgun) Send() (err error) {
 	if err != nil {
 		return err
 	}
+	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(res.Body)
 		log.Printf("[ERROR] Mailgun response bad status: %s\n", res.Status)
 		log.Printf("[ERROR] Mailgun response bad body: %s\n", string(body))
+		return fmt.Errorf("mailgun response bad status: %s", res.Status)
 	}
 
 	return err
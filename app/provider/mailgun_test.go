@@ -0,0 +1,29 @@
+package provider
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMailgunSend(t *testing.T) {
+	status := http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/example.com/messages", r.URL.Path)
+		user, password, _ := r.BasicAuth()
+		assert.Equal(t, "api:key", user+":"+password)
+		assert.Equal(t, "blog is down", r.FormValue("subject"))
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+	s := &Mailgun{Domain: "example.com", APIKey: "api:key", Values: map[string]string{"subject": "{{.Target}} is down"},
+		Provider: Provider{ID: PIDMailgun, Client: redirectClient(t, ts)}}
+
+	assert.NoError(t, s.Send(context.Background(), Alert{Target: "blog", State: StateDown}))
+
+	status = http.StatusInternalServerError
+	err := s.Send(context.Background(), Alert{Target: "blog", State: StateDown})
+	assert.EqualError(t, err, "mailgun response bad status: 500 Internal Server Error")
+}
@@ -1,14 +1,16 @@
 package provider
 
 import (
+	"context"
 	"net/http"
+	"time"
 )
 
 // ID provider enum
 type ID string
 
 type Provider struct {
-	ID ID
+	ID     ID
 	Client *http.Client
 }
 
@@ -16,13 +18,78 @@ type Provider struct {
 const (
 	PIDMailgun  ID = "mailgun"
 	PIDTelegram ID = "telegram"
+	PIDSlack    ID = "slack"
+	PIDWebhook  ID = "webhook"
+	PIDSMTP     ID = "smtp"
 )
 
+// State of the target reported by alert
+type State string
+
+// enum of all target states
+const (
+	StateDown      State = "DOWN"
+	StateRecovered State = "RECOVERED"
+	StateChanged   State = "CHANGED" // the notice which is not followed by recovery
+)
+
+// Type of the alert
+type Type string
+
+// enum of all alert types
+const (
+	TypeAvailability Type = "availability"
+	TypeCertificate  Type = "certificate"
+	TypeDigest       Type = "digest"
+	TypeDNS          Type = "dns" // the notice of the changed dns answer
+)
+
+// Alert is the event of target state change sent by providers.
+// It is the context of the message templates as well.
+type Alert struct {
+	Target     string        // the target name
+	URL        string        // the target URL
+	Type       Type          // availability, certificate, dns or digest
+	State      State         // DOWN or RECOVERED, CHANGED for dns, empty for digest
+	Reason     string        // why the last probe failed
+	StatusCode int           // the status code of the last probe, 0 if there was no response
+	Error      string        // the error text of the last probe
+	Latency    time.Duration // the duration of the last probe
+	Failures   int           // the count of consecutive failed probes
+	Downtime   time.Duration // how long the target was down, set for StateRecovered only
+	Time       time.Time     // the time of the last probe
+	StartedAt  time.Time     // the time of the first failed probe of the outage
+	ResolvedAt time.Time     // the time of the recovery, set for StateRecovered only
+	CertExpiry time.Time     // the expiry of the leaf certificate, set for HTTPS targets
+	Digest     Digest        // the summary of all targets, set for TypeDigest only
+}
+
+// Digest is the scheduled summary of all targets for the period. The digest alert has the digest title as Target.
+type Digest struct {
+	From     time.Time
+	To       time.Time
+	Targets  []DigestTarget // in the order of targets
+	Slowest  []DigestTarget // targets with the slowest probes, the slowest first
+	Expiring []DigestTarget // targets with certificates, the soonest expiring first
+}
+
+// DigestTarget is the summary of a single target in the digest
+type DigestTarget struct {
+	Name       string
+	Uptime     float64       // the percent of successful probes in the period
+	Checks     int           // probes in the period
+	Failed     int           // failed probes in the period
+	Incidents  int           // incidents overlapping the period
+	Slowest    time.Duration // the longest probe in the period
+	CertExpiry time.Time     // the expiry of the leaf certificate, zero if there was no handshake
+}
+
+// Interface of notification provider. Send should respect ctx cancellation and deadline.
 type Interface interface {
-	Send() error
+	Send(ctx context.Context, a Alert) error
 	GetID() ID
 }
 
 func (s *Provider) GetID() ID {
 	return s.ID
-}
\ No newline at end of file
+}
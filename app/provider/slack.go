@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+)
+
+// Slack provider structure for sending alert into incoming webhook
+type Slack struct {
+	WebhookURL string
+	Message    string
+	Provider   Provider
+}
+
+// colours of the attachment side bar
+const (
+	slackColorDown      = "#d00000"
+	slackColorRecovered = "#2eb886"
+	slackColorDigest    = "#439fe0"
+	slackColorNotice    = "#ecb22e"
+)
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackPayload struct {
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+// Send posting Block Kit message into slack incoming webhook
+func (s *Slack) Send(ctx context.Context, a Alert) error {
+	if s.WebhookURL == "" {
+		return fmt.Errorf("slack webhook url was not found")
+	}
+	text, err := a.Render(s.Message, DefaultTextTemplate)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(slackMessage(a, text))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", s.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := s.Provider.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		log.Printf("[ERROR] Slack response bad status: %s\n", res.Status)
+		log.Printf("[ERROR] Slack response bad body: %s\n", string(body))
+		return fmt.Errorf("slack response bad status: %s", res.Status)
+	}
+	return nil
+}
+
+// GetID get Provider ID
+func (s *Slack) GetID() ID {
+	return s.Provider.GetID()
+}
+
+// slackMessage makes the payload with colour-coded attachment for the alert state
+func slackMessage(a Alert, text string) slackPayload {
+	if a.Type == TypeDigest {
+		return slackPayload{
+			Text: text,
+			Attachments: []slackAttachment{{
+				Color: slackColorDigest,
+				Blocks: []slackBlock{
+					{Type: "header", Text: &slackText{Type: "plain_text", Text: ":bar_chart: " + a.Target}},
+					{Type: "section", Text: &slackText{Type: "mrkdwn", Text: "```" + text + "```"}},
+				},
+			}},
+		}
+	}
+	color, title := slackColorDown, fmt.Sprintf(":red_circle: %s is down", a.Target)
+	if a.Type == TypeCertificate {
+		title = fmt.Sprintf(":warning: %s certificate problem", a.Target)
+	}
+	fields := []slackText{
+		{Type: "mrkdwn", Text: fmt.Sprintf("*URL*\n%s", a.URL)},
+		{Type: "mrkdwn", Text: fmt.Sprintf("*Failures*\n%d", a.Failures)},
+	}
+	if a.Reason != "" {
+		fields = append(fields, slackText{Type: "mrkdwn", Text: fmt.Sprintf("*Reason*\n%s", a.Reason)})
+	}
+	if a.State == StateRecovered {
+		color, title = slackColorRecovered, fmt.Sprintf(":large_green_circle: %s is recovered", a.Target)
+		fields = []slackText{
+			{Type: "mrkdwn", Text: fmt.Sprintf("*URL*\n%s", a.URL)},
+			{Type: "mrkdwn", Text: fmt.Sprintf("*Downtime*\n%s", a.Downtime)},
+		}
+	}
+	if a.Type == TypeDNS {
+		color, title = slackColorNotice, fmt.Sprintf(":information_source: %s dns answer is changed", a.Target)
+		fields = []slackText{
+			{Type: "mrkdwn", Text: fmt.Sprintf("*Name*\n%s", a.URL)},
+			{Type: "mrkdwn", Text: fmt.Sprintf("*Change*\n%s", a.Reason)},
+		}
+	}
+	if a.Type == TypeCertificate && !a.CertExpiry.IsZero() {
+		fields = append(fields, slackText{Type: "mrkdwn", Text: fmt.Sprintf("*Certificate expiry*\n%s", a.CertExpiry.Format("2006-01-02"))})
+	}
+
+	return slackPayload{
+		Text: text,
+		Attachments: []slackAttachment{{
+			Color: color,
+			Blocks: []slackBlock{
+				{Type: "header", Text: &slackText{Type: "plain_text", Text: title}},
+				{Type: "section", Text: &slackText{Type: "mrkdwn", Text: text}},
+				{Type: "section", Fields: fields},
+				{Type: "context", Elements: []slackText{
+					{Type: "mrkdwn", Text: a.Time.Format("2006-01-02 15:04:05 MST")},
+				}},
+			},
+		}},
+	}
+}
@@ -0,0 +1,57 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSlackSend(t *testing.T) {
+	var payload slackPayload
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+	}))
+	defer ts.Close()
+
+	s := &Slack{
+		WebhookURL: ts.URL,
+		Message:    "{{.Target}}: {{.Reason}}",
+		Provider:   Provider{ID: PIDSlack, Client: ts.Client()},
+	}
+	err := s.Send(context.Background(), Alert{Target: "blog", State: StateDown, Reason: "bad status code 502"})
+	assert.NoError(t, err)
+	assert.Equal(t, "blog: bad status code 502", payload.Text)
+	assert.Equal(t, slackColorDown, payload.Attachments[0].Color)
+	assert.Equal(t, "header", payload.Attachments[0].Blocks[0].Type)
+
+	err = s.Send(context.Background(), Alert{Target: "blog", State: StateRecovered, Downtime: time.Minute})
+	assert.NoError(t, err)
+	assert.Equal(t, slackColorRecovered, payload.Attachments[0].Color)
+	assert.Equal(t, "[blog] RECOVERED:  is up again after 1m0s of downtime", payload.Text,
+		"the message without .State is not used for recovery")
+
+	s.Message = `{{.Target}} is {{if eq .State "RECOVERED"}}up{{else}}down: {{.Reason}}{{end}}`
+	err = s.Send(context.Background(), Alert{Target: "blog", State: StateRecovered, Downtime: time.Minute})
+	assert.NoError(t, err)
+	assert.Equal(t, "blog is up", payload.Text, "the message with .State renders the recovery itself")
+
+	s.Message = ""
+	err = s.Send(context.Background(), Alert{Target: "blog", State: StateRecovered, Downtime: time.Minute})
+	assert.NoError(t, err)
+	assert.Contains(t, payload.Text, "RECOVERED")
+}
+
+func TestSlackSendBadStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	s := &Slack{WebhookURL: ts.URL, Provider: Provider{ID: PIDSlack, Client: ts.Client()}}
+	assert.Error(t, s.Send(context.Background(), Alert{Target: "blog"}))
+}
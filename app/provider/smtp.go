@@ -0,0 +1,275 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTP connection security modes
+const (
+	SMTPPlain    = "plain"
+	SMTPStartTLS = "starttls"
+	SMTPTLS      = "tls"
+)
+
+// SMTP auth mechanisms
+const (
+	SMTPAuthPlain = "plain"
+	SMTPAuthLogin = "login"
+)
+
+// DefaultSMTPPort is the submission port used with starttls
+const DefaultSMTPPort = 587
+
+// SMTP provider structure for sending email notification through own mail relay
+type SMTP struct {
+	Host     string
+	Port     int
+	Security string // plain, starttls or tls (implicit)
+	Auth     string // plain or login, used if Username is set
+	Username string
+	Password string
+	From     string
+	To       string // comma separated list
+	Cc       string // comma separated list
+	Subject  string
+	Text     string
+	HTML     string
+	Provider Provider
+}
+
+// Send sending multipart text and html email via SMTP server
+func (s *SMTP) Send(ctx context.Context, a Alert) error {
+	rcpt := append(splitAddresses(s.To), splitAddresses(s.Cc)...)
+	if len(rcpt) == 0 {
+		return fmt.Errorf("smtp recipients were not found")
+	}
+	msg, err := s.message(a)
+	if err != nil {
+		return err
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	c, err := s.client(ctx, stop)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if s.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return fmt.Errorf("smtp server %s doesn't support AUTH", s.Host)
+		}
+		if err = c.Auth(s.auth()); err != nil {
+			return fmt.Errorf("smtp auth failed: %w", err)
+		}
+	}
+	if err = c.Mail(s.From); err != nil {
+		return err
+	}
+	for _, addr := range rcpt {
+		if err = c.Rcpt(addr); err != nil {
+			return fmt.Errorf("smtp recipient %s is rejected: %w", addr, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// GetID get Provider ID
+func (s *SMTP) GetID() ID {
+	return s.Provider.GetID()
+}
+
+// Validate checks the connection security and the auth mechanism
+func (s *SMTP) Validate() error {
+	switch s.Security {
+	case SMTPPlain, SMTPStartTLS, SMTPTLS:
+	default:
+		return fmt.Errorf("smtp security %q is not one of plain, starttls, tls", s.Security)
+	}
+	if !strings.EqualFold(s.Auth, SMTPAuthPlain) && !strings.EqualFold(s.Auth, SMTPAuthLogin) {
+		return fmt.Errorf("smtp auth %q is not one of plain, login", s.Auth)
+	}
+	return nil
+}
+
+// client dials the server with the configured security, the connection is interrupted when ctx is done before stop
+func (s *SMTP) client(ctx context.Context, stop <-chan struct{}) (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	tlsConfig := &tls.Config{ServerName: s.Host, MinVersion: tls.VersionTLS12}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{}
+	switch s.Security {
+	case SMTPTLS:
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	case SMTPPlain, SMTPStartTLS, "":
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	default:
+		return nil, fmt.Errorf("smtp security %q is not supported", s.Security)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.SetDeadline(time.Now())
+		case <-stop:
+		}
+	}()
+
+	c, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if s.Security == SMTPStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			c.Close()
+			return nil, fmt.Errorf("smtp server %s doesn't support STARTTLS", s.Host)
+		}
+		if err = c.StartTLS(tlsConfig); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (s *SMTP) auth() smtp.Auth {
+	if strings.EqualFold(s.Auth, SMTPAuthLogin) {
+		return &loginAuth{username: s.Username, password: s.Password, host: s.Host}
+	}
+	return smtp.PlainAuth("", s.Username, s.Password, s.Host)
+}
+
+// message builds the MIME message with text and html alternatives
+func (s *SMTP) message(a Alert) ([]byte, error) {
+	subject, err := a.Render(s.Subject, DefaultSubjectTemplate)
+	if err != nil {
+		return nil, err
+	}
+	text, err := a.Render(s.Text, DefaultTextTemplate)
+	if err != nil {
+		return nil, err
+	}
+	html, err := a.RenderHTML(s.HTML, "<p>"+DefaultTextTemplate+"</p>")
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	body := multipart.NewWriter(&b)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	} {
+		pw, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err = qw.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err = qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err = body.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	headers := [][2]string{
+		{"From", s.From},
+		{"To", strings.Join(splitAddresses(s.To), ", ")},
+		{"Cc", strings.Join(splitAddresses(s.Cc), ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + body.Boundary()},
+	}
+	for _, h := range headers {
+		if h[1] == "" {
+			continue
+		}
+		fmt.Fprintf(&msg, "%s: %s\r\n", h[0], h[1])
+	}
+	msg.WriteString("\r\n")
+	msg.Write(b.Bytes())
+	return msg.Bytes(), nil
+}
+
+func splitAddresses(list string) []string {
+	var res []string
+	for _, addr := range strings.Split(list, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			res = append(res, addr)
+		}
+	}
+	return res
+}
+
+// loginAuth implements LOGIN mechanism which is not supported by net/smtp
+type loginAuth struct {
+	username, password, host string
+}
+
+func (l *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != l.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (l *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(l.username), nil
+	case "password:":
+		return []byte(l.password), nil
+	}
+	return nil, fmt.Errorf("unexpected server challenge: %s", fromServer)
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSession is what the fake server received
+type smtpSession struct {
+	auth []string
+	from string
+	rcpt []string
+	data string
+}
+
+// fakeSMTP serves the single SMTP session with LOGIN auth support
+func fakeSMTP(t *testing.T) (port int, result <-chan smtpSession) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan smtpSession, 1)
+	go func() {
+		defer ln.Close()
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		var s smtpSession
+		_ = tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch cmd {
+			case "EHLO":
+				_ = tp.PrintfLine("250-localhost")
+				_ = tp.PrintfLine("250 AUTH LOGIN")
+			case "AUTH":
+				_ = tp.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte("Username:")))
+				user, _ := tp.ReadLine()
+				_ = tp.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte("Password:")))
+				pass, _ := tp.ReadLine()
+				for _, v := range []string{user, pass} {
+					b, _ := base64.StdEncoding.DecodeString(v)
+					s.auth = append(s.auth, string(b))
+				}
+				_ = tp.PrintfLine("235 ok")
+			case "MAIL":
+				s.from = line
+				_ = tp.PrintfLine("250 ok")
+			case "RCPT":
+				s.rcpt = append(s.rcpt, line)
+				_ = tp.PrintfLine("250 ok")
+			case "DATA":
+				_ = tp.PrintfLine("354 go ahead")
+				b, _ := tp.ReadDotBytes()
+				s.data = string(b)
+				_ = tp.PrintfLine("250 ok")
+			case "QUIT":
+				_ = tp.PrintfLine("221 bye")
+				ch <- s
+				return
+			default:
+				_ = tp.PrintfLine("502 unknown")
+			}
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, ch
+}
+
+func TestSMTPSend(t *testing.T) {
+	port, result := fakeSMTP(t)
+	s := &SMTP{
+		Host:     "127.0.0.1",
+		Port:     port,
+		Security: SMTPPlain,
+		Auth:     SMTPAuthLogin,
+		Username: "user",
+		Password: "pass",
+		From:     "hhchecker@example.com",
+		To:       "a@example.com, b@example.com",
+		Cc:       "c@example.com",
+		Subject:  "{{.Target}} is {{.State}}",
+		HTML:     "<b>{{.Reason}}</b>",
+		Provider: Provider{ID: PIDSMTP},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := s.Send(ctx, Alert{Target: "blog", URL: "https://theshamuel.com", State: StateDown, Reason: "<timeout>"})
+	assert.NoError(t, err)
+
+	session := <-result
+	assert.Equal(t, []string{"user", "pass"}, session.auth)
+	assert.Equal(t, "MAIL FROM:<hhchecker@example.com>", session.from)
+	assert.Equal(t, []string{"RCPT TO:<a@example.com>", "RCPT TO:<b@example.com>", "RCPT TO:<c@example.com>"}, session.rcpt)
+	assert.Contains(t, session.data, "Subject: blog is DOWN")
+	assert.Contains(t, session.data, "To: a@example.com, b@example.com")
+	assert.Contains(t, session.data, "Cc: c@example.com")
+	assert.Contains(t, session.data, "Content-Type: multipart/alternative")
+	assert.Contains(t, session.data, "Content-Type: text/plain; charset=utf-8")
+	assert.Contains(t, session.data, "[blog] DOWN: https://theshamuel.com <timeout>")
+	assert.Contains(t, session.data, "<b>&lt;timeout&gt;</b>")
+}
+
+func TestSMTPSendErrors(t *testing.T) {
+	s := &SMTP{Host: "127.0.0.1", Port: 1, Provider: Provider{ID: PIDSMTP}}
+	assert.EqualError(t, s.Send(context.Background(), Alert{}), "smtp recipients were not found")
+
+	s.To = "a@example.com"
+	s.Security = "ssl"
+	assert.EqualError(t, s.Send(context.Background(), Alert{}), `smtp security "ssl" is not supported`)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+	s.Security, s.Port = SMTPPlain, ln.Addr().(*net.TCPAddr).Port
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Error(t, s.Send(ctx, Alert{}), "server never greets, deadline should interrupt")
+}
@@ -1,10 +1,12 @@
 package provider
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 )
 
 // Telegram provider structure for sending email notification
@@ -17,7 +19,7 @@ type Telegram struct {
 }
 
 // Send sending text message into public telegram channel
-func (s *Telegram) Send() error {
+func (s *Telegram) Send(ctx context.Context, a Alert) error {
 	urlPattern := "https://api.telegram.org/bot%s/sendMessage?chat_id=%s&text=%s"
 	channel := s.ChannelID
 	if len(channel) == 0 && len(s.ChannelName) > 0 {
@@ -26,8 +28,13 @@ func (s *Telegram) Send() error {
 	if len(channel) == 0 {
 		return fmt.Errorf("channel ID and channel name were not found")
 	}
-	log.Printf("[DEBUG] telegram url: %s", fmt.Sprintf(urlPattern, s.BotAPIKey, channel, s.Message))
-	req, err := http.NewRequest("GET", fmt.Sprintf(urlPattern, s.BotAPIKey, channel, s.Message), nil)
+	text, err := a.Render(s.Message, DefaultTextTemplate)
+	if err != nil {
+		return err
+	}
+	message := url.QueryEscape(text)
+	log.Printf("[DEBUG] telegram url: %s", fmt.Sprintf(urlPattern, s.BotAPIKey, channel, message))
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf(urlPattern, s.BotAPIKey, channel, message), nil)
 	if err != nil {
 		return err
 	}
@@ -35,10 +42,12 @@ func (s *Telegram) Send() error {
 	if err != nil {
 		return err
 	}
+	defer res.Body.Close()
 	if res.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(res.Body)
 		log.Printf("[ERROR] Telegram response bad status: %s\n", res.Status)
 		log.Printf("[ERROR] Telegram response bad body: %s\n", string(body))
+		return fmt.Errorf("telegram response bad status: %s", res.Status)
 	}
 	return nil
 }
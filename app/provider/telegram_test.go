@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// redirectTransport sends every request to the test server instead of the provider api
+type redirectTransport struct{ to *url.URL }
+
+func (t redirectTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme, r.URL.Host = t.to.Scheme, t.to.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func redirectClient(t *testing.T, ts *httptest.Server) *http.Client {
+	to, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+	return &http.Client{Transport: redirectTransport{to: to}}
+}
+
+func TestTelegramSend(t *testing.T) {
+	status := http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/botkey/sendMessage", r.URL.Path)
+		assert.Equal(t, "@alerts", r.URL.Query().Get("chat_id"))
+		assert.Equal(t, "blog is down", r.URL.Query().Get("text"))
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+	s := &Telegram{BotAPIKey: "key", ChannelName: "alerts", Message: "{{.Target}} is down",
+		Provider: Provider{ID: PIDTelegram, Client: redirectClient(t, ts)}}
+
+	assert.NoError(t, s.Send(context.Background(), Alert{Target: "blog", State: StateDown}))
+
+	status = http.StatusInternalServerError
+	err := s.Send(context.Background(), Alert{Target: "blog", State: StateDown})
+	assert.EqualError(t, err, "telegram response bad status: 500 Internal Server Error")
+}
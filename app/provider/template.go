@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+)
+
+// default templates are used when the provider message is not set
+const (
+	DefaultSubjectTemplate = `[{{.Target}}] {{if eq .Type "certificate"}}CERTIFICATE {{else if eq .Type "dns"}}DNS {{end}}{{.State}}`
+	DefaultTextTemplate    = `{{if eq .Type "certificate"}}[{{.Target}}] CERTIFICATE: {{.URL}} {{.Reason}}` +
+		`{{else if eq .Type "dns"}}[{{.Target}}] DNS CHANGED: {{.URL}} {{.Reason}}` +
+		`{{else}}[{{.Target}}] DOWN: {{.URL}} {{.Reason}}` +
+		` after {{.Failures}} consecutive failed probe(s){{end}} at {{.Time.Format "2006-01-02 15:04:05"}}`
+	RecoveryTemplate = `{{if eq .Type "certificate"}}[{{.Target}}] RECOVERED: certificate of {{.URL}} is valid` +
+		` until {{.CertExpiry.Format "2006-01-02"}}{{else}}[{{.Target}}] RECOVERED: {{.URL}} is up again` +
+		` after {{.Downtime}} of downtime{{end}}`
+	DefaultWebhookTemplate = `{"target":{{json .Target}},"url":{{json .URL}},"type":{{json .Type}},"state":{{json .State}},` +
+		`"reason":{{json .Reason}},"status_code":{{.StatusCode}},"latency_ms":{{.Latency.Milliseconds}},` +
+		`"failures":{{.Failures}},"downtime_sec":{{.Downtime.Seconds}},"time":{{json .Time}},` +
+		`"started_at":{{json .StartedAt}},"resolved_at":{{json .ResolvedAt}},"cert_expiry":{{json .CertExpiry}}}`
+)
+
+// digest templates are always used for TypeDigest instead of the provider message
+const (
+	DigestSubjectTemplate = `[{{.Target}}] {{.Digest.From.Format "2006-01-02 15:04"}} - {{.Digest.To.Format "2006-01-02 15:04"}}`
+	DigestTextTemplate    = `{{.Target}} from {{.Digest.From.Format "2006-01-02 15:04"}} to {{.Digest.To.Format "2006-01-02 15:04"}}` +
+		"\n\nUptime:\n{{range .Digest.Targets}}{{.Name}}: {{printf \"%.2f\" .Uptime}}%, {{.Incidents}} incident(s)\n{{end}}" +
+		"{{if .Digest.Slowest}}\nSlowest responses:\n{{range .Digest.Slowest}}{{.Name}}: {{.Slowest}}\n{{end}}{{end}}" +
+		"{{if .Digest.Expiring}}\nExpiring certificates:\n{{range .Digest.Expiring}}{{.Name}}: {{.CertExpiry.Format \"2006-01-02\"}}\n{{end}}{{end}}"
+	DigestWebhookTemplate = `{"type":"digest","title":{{json .Target}},"from":{{json .Digest.From}},"to":{{json .Digest.To}},"targets":[` +
+		`{{range $i, $t := .Digest.Targets}}{{if $i}},{{end}}{"name":{{json $t.Name}},"uptime_percent":{{$t.Uptime}},` +
+		`"checks":{{$t.Checks}},"failed_checks":{{$t.Failed}},"incidents":{{$t.Incidents}},` +
+		`"slowest_ms":{{$t.Slowest.Milliseconds}},"cert_expiry":{{json $t.CertExpiry}}}{{end}}]}`
+)
+
+// funcs available in templates, json quotes the value to be embedded into JSON body
+var funcs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// Render executes the text template with the alert as a context.
+// The empty text is replaced by the default one and the static text is prefixed by the target. The recovery and
+// the dns change are rendered by the template only if it refers to .State, otherwise by RecoveryTemplate and the default
+// text (DefaultSubjectTemplate for the subject), so the message written for the down alert is sent for it only.
+// The digest uses DigestSubjectTemplate for the subject and DigestTextTemplate for others.
+func (a Alert) Render(text, defaultText string) (string, error) {
+	switch {
+	case a.Type == TypeDigest && defaultText == DefaultSubjectTemplate:
+		text = DigestSubjectTemplate
+	case a.Type == TypeDigest:
+		text = DigestTextTemplate
+	case a.State == StateRecovered && !refersState(text) && defaultText == DefaultSubjectTemplate:
+		text = DefaultSubjectTemplate
+	case a.State == StateRecovered && !refersState(text):
+		text = RecoveryTemplate
+	case a.State == StateChanged && !refersState(text), text == "":
+		text = defaultText
+	default:
+		text = withTarget(text)
+	}
+	return a.Execute(text)
+}
+
+// refersState checks if the template branches on the alert state to render the recovery itself
+func refersState(text string) bool {
+	return strings.Contains(text, ".State")
+}
+
+// withTarget prefixes the static text without template actions by the target name,
+// so the fixed message of several targets still says which one failed
+func withTarget(text string) string {
+	if strings.Contains(text, "{{") {
+		return text
+	}
+	return "[{{.Target}}] " + text
+}
+
+// Execute executes the text template with the alert as a context as is, for both states
+func (a Alert) Execute(text string) (string, error) {
+	tmpl, err := template.New("message").Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("can't parse message template: %w", err)
+	}
+	var b strings.Builder
+	if err = tmpl.Execute(&b, a); err != nil {
+		return "", fmt.Errorf("can't execute message template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// RenderHTML is the same as Render but the values are escaped for html
+func (a Alert) RenderHTML(text, defaultText string) (string, error) {
+	switch {
+	case a.Type == TypeDigest:
+		text = "<pre>" + DigestTextTemplate + "</pre>"
+	case a.State == StateRecovered && !refersState(text):
+		text = "<p>" + RecoveryTemplate + "</p>"
+	case a.State == StateChanged && !refersState(text), text == "":
+		text = defaultText
+	default:
+		text = withTarget(text)
+	}
+	tmpl, err := htmltemplate.New("message").Funcs(htmltemplate.FuncMap(funcs)).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("can't parse html template: %w", err)
+	}
+	var b strings.Builder
+	if err = tmpl.Execute(&b, a); err != nil {
+		return "", fmt.Errorf("can't execute html template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// CheckTemplates parses and executes every template with the empty alert
+func CheckTemplates(texts ...string) error {
+	for _, text := range texts {
+		if _, err := (Alert{}).Execute(text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
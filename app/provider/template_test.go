@@ -0,0 +1,132 @@
+package provider
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	a := Alert{
+		Target:     "blog",
+		URL:        "https://theshamuel.com",
+		State:      StateDown,
+		Reason:     "bad status 502",
+		StatusCode: 502,
+		Failures:   3,
+		Latency:    120 * time.Millisecond,
+		Time:       time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC),
+	}
+
+	text, err := a.Render("{{.Target}} {{.StatusCode}} {{.Latency}} {{.Failures}}", DefaultTextTemplate)
+	assert.NoError(t, err)
+	assert.Equal(t, "blog 502 120ms 3", text)
+
+	text, err = a.Render("", DefaultTextTemplate)
+	assert.NoError(t, err)
+	assert.Equal(t, "[blog] DOWN: https://theshamuel.com bad status 502 after 3 consecutive failed probe(s) at 2023-05-01 10:00:00", text)
+
+	a.State = StateRecovered
+	a.Downtime = 5 * time.Minute
+	text, err = a.Render("{{.Target}} is {{if eq .State \"RECOVERED\"}}up after {{.Downtime}}{{else}}down{{end}}", DefaultTextTemplate)
+	assert.NoError(t, err)
+	assert.Equal(t, "blog is up after 5m0s", text, "user template with .State renders recovery")
+
+	text, err = a.Render("{{.Target}} is DOWN: {{.Reason}}", DefaultTextTemplate)
+	assert.NoError(t, err)
+	assert.Equal(t, "[blog] RECOVERED: https://theshamuel.com is up again after 5m0s of downtime", text,
+		"user template without .State is for the down alert only")
+
+	text, err = a.Render("", DefaultTextTemplate)
+	assert.NoError(t, err)
+	assert.Equal(t, "[blog] RECOVERED: https://theshamuel.com is up again after 5m0s of downtime", text)
+
+	text, err = a.Render("", DefaultSubjectTemplate)
+	assert.NoError(t, err)
+	assert.Equal(t, "[blog] RECOVERED", text)
+
+	html, err := a.RenderHTML("<b>{{.Target}} {{.State}}</b>", "<p>"+DefaultTextTemplate+"</p>")
+	assert.NoError(t, err)
+	assert.Equal(t, "<b>blog RECOVERED</b>", html)
+}
+
+func TestRenderChanged(t *testing.T) {
+	a := Alert{Target: "dns", URL: "app.example.test", Type: TypeDNS, State: StateChanged,
+		Reason: "dns A answer changed from [10.0.0.1] to [10.0.0.2]"}
+
+	subject, err := a.Render("", DefaultSubjectTemplate)
+	assert.NoError(t, err)
+	assert.Equal(t, "[dns] DNS CHANGED", subject)
+
+	text, err := a.Render("{{.Target}} is DOWN: {{.Reason}}", DefaultTextTemplate)
+	assert.NoError(t, err)
+	assert.Equal(t, "[dns] DNS CHANGED: app.example.test dns A answer changed from [10.0.0.1] to [10.0.0.2] at 0001-01-01 00:00:00", text,
+		"user template without .State is for the down alert only")
+}
+
+func TestRenderStaticText(t *testing.T) {
+	a := Alert{Target: "<blog>", State: StateDown}
+	text, err := a.Render("site down", DefaultTextTemplate)
+	assert.NoError(t, err)
+	assert.Equal(t, "[<blog>] site down", text)
+
+	text, err = a.Render("Site alert", DefaultSubjectTemplate)
+	assert.NoError(t, err)
+	assert.Equal(t, "[<blog>] Site alert", text)
+
+	a.State = StateRecovered
+	text, err = a.Render("site down", DefaultTextTemplate)
+	assert.NoError(t, err)
+	assert.Equal(t, "[<blog>] RECOVERED:  is up again after 0s of downtime", text, "built-in recovery")
+
+	text, err = a.Render("Site alert", DefaultSubjectTemplate)
+	assert.NoError(t, err)
+	assert.Equal(t, "[<blog>] RECOVERED", text, "built-in recovery subject")
+
+	html, err := a.RenderHTML("<p>site down</p>", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "<p>[&lt;blog&gt;] RECOVERED:  is up again after 0s of downtime</p>", html)
+}
+
+func TestCheckTemplates(t *testing.T) {
+	assert.NoError(t, CheckTemplates("", "plain text", "{{.Target}} {{.Error}}"))
+	assert.Error(t, CheckTemplates("{{.Target"))
+	assert.Error(t, CheckTemplates("{{.Unknown}}"))
+}
+
+func TestRenderDigest(t *testing.T) {
+	from := time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)
+	a := Alert{Target: "Daily digest", Type: TypeDigest, Time: from.Add(24 * time.Hour), Digest: digest(from)}
+
+	subject, err := a.Render("{{.Target}} is down", DefaultSubjectTemplate)
+	assert.NoError(t, err)
+	assert.Equal(t, "[Daily digest] 2023-05-01 09:00 - 2023-05-02 09:00", subject)
+
+	text, err := a.Render("{{.Target}} is down", DefaultTextTemplate)
+	assert.NoError(t, err)
+	assert.Equal(t, `Daily digest from 2023-05-01 09:00 to 2023-05-02 09:00
+
+Uptime:
+blog: 99.93%, 1 incident(s)
+redis: 100.00%, 0 incident(s)
+
+Slowest responses:
+blog: 2.5s
+redis: 3ms
+
+Expiring certificates:
+blog: 2023-06-01
+`, text)
+
+	html, err := a.RenderHTML("<b>{{.Target}}</b>", "")
+	assert.NoError(t, err)
+	assert.Contains(t, html, "<pre>Daily digest from")
+}
+
+func digest(from time.Time) Digest {
+	blog := DigestTarget{Name: "blog", Uptime: 99.93, Checks: 1440, Failed: 1, Incidents: 1, Slowest: 2500 * time.Millisecond,
+		CertExpiry: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)}
+	redis := DigestTarget{Name: "redis", Uptime: 100, Checks: 1440, Slowest: 3 * time.Millisecond}
+	return Digest{From: from, To: from.Add(24 * time.Hour), Targets: []DigestTarget{blog, redis},
+		Slowest: []DigestTarget{blog, redis}, Expiring: []DigestTarget{blog}}
+}
@@ -0,0 +1,87 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+)
+
+// SignatureHeader is the header with HMAC-SHA256 of the webhook body in form sha256=<hex>
+const SignatureHeader = "X-Hhchecker-Signature"
+
+// Webhook provider structure for sending alert into any HTTP endpoint
+type Webhook struct {
+	Method   string
+	URL      string
+	Headers  map[string]string
+	Body     string // JSON body template, DefaultWebhookTemplate if empty, DigestWebhookTemplate is used for digest
+	Secret   string // the key of HMAC-SHA256 signature, the body is not signed if empty
+	Provider Provider
+}
+
+// Send sending JSON body rendered for the alert into webhook URL
+func (s *Webhook) Send(ctx context.Context, a Alert) error {
+	if s.URL == "" {
+		return fmt.Errorf("webhook url was not found")
+	}
+	tmpl := s.Body
+	if tmpl == "" {
+		tmpl = DefaultWebhookTemplate
+	}
+	if a.Type == TypeDigest {
+		tmpl = DigestWebhookTemplate
+	}
+	body, err := a.Execute(tmpl)
+	if err != nil {
+		return err
+	}
+	if !json.Valid([]byte(body)) {
+		return fmt.Errorf("webhook body is not valid JSON: %s", body)
+	}
+	method := s.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.URL, bytes.NewReader([]byte(body)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+	if s.Secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign([]byte(body), s.Secret))
+	}
+	res, err := s.Provider.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		body, _ := io.ReadAll(res.Body)
+		log.Printf("[ERROR] Webhook response bad status: %s\n", res.Status)
+		log.Printf("[ERROR] Webhook response bad body: %s\n", string(body))
+		return fmt.Errorf("webhook response bad status: %s", res.Status)
+	}
+	return nil
+}
+
+// GetID get Provider ID
+func (s *Webhook) GetID() ID {
+	return s.Provider.GetID()
+}
+
+// Sign returns hex encoded HMAC-SHA256 of the payload, the receiver can compare it with SignatureHeader
+func Sign(payload []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookSendDefaultBody(t *testing.T) {
+	var payload map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "sha256="+Sign(body, "secret"), r.Header.Get(SignatureHeader))
+		assert.NoError(t, json.Unmarshal(body, &payload))
+	}))
+	defer ts.Close()
+
+	s := &Webhook{
+		Method:   "PUT",
+		URL:      ts.URL,
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+		Secret:   "secret",
+		Provider: Provider{ID: PIDWebhook, Client: ts.Client()},
+	}
+	err := s.Send(context.Background(), Alert{
+		Target:  "blog",
+		State:   StateDown,
+		Reason:  `bad "status"`,
+		Latency: 1500 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "blog", payload["target"])
+	assert.Equal(t, "DOWN", payload["state"])
+	assert.Equal(t, `bad "status"`, payload["reason"])
+	assert.Equal(t, float64(1500), payload["latency_ms"])
+}
+
+func TestWebhookSendCustomBody(t *testing.T) {
+	var body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		assert.Empty(t, r.Header.Get(SignatureHeader))
+	}))
+	defer ts.Close()
+
+	s := &Webhook{
+		URL:      ts.URL,
+		Body:     `{"summary": {{json .Target}}, "severity": "{{if eq .State "DOWN"}}critical{{else}}info{{end}}"}`,
+		Provider: Provider{ID: PIDWebhook, Client: ts.Client()},
+	}
+	assert.NoError(t, s.Send(context.Background(), Alert{Target: "blog", State: StateRecovered}))
+	assert.Equal(t, `{"summary": "blog", "severity": "info"}`, body)
+
+	s.Body = `{"summary": {{.Target}}}`
+	assert.Error(t, s.Send(context.Background(), Alert{Target: "blog"}), "invalid JSON")
+}
+
+func TestWebhookSendDigest(t *testing.T) {
+	var payload struct {
+		Type    string    `json:"type"`
+		Title   string    `json:"title"`
+		From    time.Time `json:"from"`
+		Targets []struct {
+			Name      string    `json:"name"`
+			Uptime    float64   `json:"uptime_percent"`
+			Incidents int       `json:"incidents"`
+			SlowestMs int64     `json:"slowest_ms"`
+			Expiry    time.Time `json:"cert_expiry"`
+		} `json:"targets"`
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+	}))
+	defer ts.Close()
+
+	from := time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)
+	s := &Webhook{URL: ts.URL, Body: `{"summary": {{json .Target}}}`, Provider: Provider{ID: PIDWebhook, Client: ts.Client()}}
+	assert.NoError(t, s.Send(context.Background(), Alert{Target: "Daily digest", Type: TypeDigest, Digest: digest(from)}))
+	assert.Equal(t, "digest", payload.Type, "the custom body is not used for digest")
+	assert.Equal(t, "Daily digest", payload.Title)
+	assert.Equal(t, from, payload.From)
+	assert.Len(t, payload.Targets, 2)
+	assert.Equal(t, 99.93, payload.Targets[0].Uptime)
+	assert.Equal(t, int64(2500), payload.Targets[0].SlowestMs)
+	assert.Equal(t, 2023, payload.Targets[0].Expiry.Year())
+	assert.True(t, payload.Targets[1].Expiry.IsZero())
+}
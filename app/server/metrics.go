@@ -0,0 +1,122 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/theshamuel/hhchecker/app/checker"
+	"github.com/theshamuel/hhchecker/app/provider"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// phases of probe duration histogram in the order of the request
+var phases = []string{"dns", "connect", "tls", "ttfb", "total"}
+
+// metrics writes the probe results and notification counters in Prometheus text format
+func (s *Server) metrics(w http.ResponseWriter, r *http.Request) {
+	statuses := s.Scheduler.Statuses()
+	buf := &bytes.Buffer{}
+
+	header(buf, "hhchecker_probe_success", "gauge", "Whether the last probe of the target succeeded.")
+	for _, st := range statuses {
+		if st.Checks > 0 {
+			sample(buf, "hhchecker_probe_success", labels("target", st.Name, "type", st.Type), boolValue(st.Last.OK()))
+		}
+	}
+
+	header(buf, "hhchecker_probe_duration_seconds", "histogram", "Duration of the probe phases, dns, connect and tls are skipped for reused connection.")
+	for _, st := range statuses {
+		for _, phase := range phases {
+			h, ok := st.Durations[phase]
+			if !ok {
+				continue
+			}
+			for i, bound := range checker.DurationBuckets {
+				sample(buf, "hhchecker_probe_duration_seconds_bucket",
+					labels("target", st.Name, "phase", phase, "le", formatFloat(bound)), float64(h.Buckets[i]))
+			}
+			sample(buf, "hhchecker_probe_duration_seconds_bucket", labels("target", st.Name, "phase", phase, "le", "+Inf"), float64(h.Count))
+			sample(buf, "hhchecker_probe_duration_seconds_sum", labels("target", st.Name, "phase", phase), h.Sum)
+			sample(buf, "hhchecker_probe_duration_seconds_count", labels("target", st.Name, "phase", phase), float64(h.Count))
+		}
+	}
+
+	header(buf, "hhchecker_probe_status_code", "gauge", "HTTP status code of the last probe, 0 without response.")
+	for _, st := range statuses {
+		if st.Checks > 0 && (st.Type == checker.TypeHTTP || st.Type == checker.TypeGRPC || st.Type == checker.TypeWebSocket) {
+			sample(buf, "hhchecker_probe_status_code", labels("target", st.Name), float64(st.Last.StatusCode))
+		}
+	}
+
+	header(buf, "hhchecker_consecutive_failures", "gauge", "Failed probes of the target in a row.")
+	for _, st := range statuses {
+		sample(buf, "hhchecker_consecutive_failures", labels("target", st.Name), float64(st.Consecutive))
+	}
+
+	header(buf, "hhchecker_probes_total", "counter", "Probes of the target since the start.")
+	for _, st := range statuses {
+		sample(buf, "hhchecker_probes_total", labels("target", st.Name), float64(st.Checks))
+	}
+
+	header(buf, "hhchecker_probe_failures_total", "counter", "Failed probes of the target since the start.")
+	for _, st := range statuses {
+		sample(buf, "hhchecker_probe_failures_total", labels("target", st.Name), float64(st.Failed))
+	}
+
+	header(buf, "hhchecker_cert_expiry_timestamp_seconds", "gauge", "Expiry of the leaf certificate of the target as unix time.")
+	for _, st := range statuses {
+		if st.Last.Cert != nil && !st.Last.Cert.NotAfter.IsZero() {
+			sample(buf, "hhchecker_cert_expiry_timestamp_seconds", labels("target", st.Name), float64(st.Last.Cert.NotAfter.Unix()))
+		}
+	}
+
+	header(buf, "hhchecker_notifications_total", "counter", "Alerts sent by the provider, result is success or failure.")
+	counts := s.Scheduler.Notifications()
+	ids := make([]string, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, string(id))
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		c := counts[provider.ID(id)]
+		sample(buf, "hhchecker_notifications_total", labels("provider", id, "result", "success"), float64(c.Sent))
+		sample(buf, "hhchecker_notifications_total", labels("provider", id, "result", "failure"), float64(c.Failed))
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Printf("[WARN] can't write metrics: %v", err)
+	}
+}
+
+func header(buf *bytes.Buffer, name, typ, help string) {
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func sample(buf *bytes.Buffer, name, labels string, value float64) {
+	fmt.Fprintf(buf, "%s{%s} %s\n", name, labels, formatFloat(value))
+}
+
+// labels formats the pairs of label names and values with escaping
+func labels(pairs ...string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	res := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		res = append(res, fmt.Sprintf(`%s="%s"`, pairs[i], escaper.Replace(pairs[i+1])))
+	}
+	return strings.Join(res, ",")
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func boolValue(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
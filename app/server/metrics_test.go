@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/theshamuel/hhchecker/app/checker"
+	"github.com/theshamuel/hhchecker/app/provider"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type failingProvider struct{}
+
+func (failingProvider) Send(context.Context, provider.Alert) error { return errors.New("failed") }
+
+func (failingProvider) GetID() provider.ID { return provider.PIDSlack }
+
+func TestMetrics(t *testing.T) {
+	backend := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer backend.Close()
+	scheduler := &checker.Scheduler{
+		Targets: []checker.Target{
+			{Name: "up", URL: backend.URL, Interval: 10 * time.Millisecond, MaxAlerts: 1},
+			{Name: `say "down"`, URL: backend.URL + "/down", Interval: 10 * time.Millisecond},
+		},
+		Providers: []provider.Interface{failingProvider{}},
+		Client:    backend.Client(),
+	}
+	runScheduler(t, scheduler)
+
+	ts := httptest.NewServer((&Server{Scheduler: scheduler}).routes())
+	defer ts.Close()
+	res, err := http.Get(ts.URL + "/metrics")
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	text := string(body)
+
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", res.Header.Get("Content-Type"))
+	assert.Contains(t, text, "# TYPE hhchecker_probe_success gauge\n")
+	assert.Contains(t, text, `hhchecker_probe_success{target="up",type="http"} 1`)
+	assert.Contains(t, text, `hhchecker_probe_success{target="say \"down\"",type="http"} 0`)
+	assert.Contains(t, text, `hhchecker_probe_status_code{target="say \"down\""} 502`)
+	assert.Contains(t, text, `hhchecker_probe_duration_seconds_bucket{target="up",phase="total",le="+Inf"}`)
+	assert.Contains(t, text, `hhchecker_probe_duration_seconds_bucket{target="up",phase="tls",le="0.005"}`)
+	assert.Contains(t, text, `hhchecker_probe_duration_seconds_count{target="up",phase="ttfb"}`)
+	assert.Contains(t, text, `hhchecker_consecutive_failures{target="up"} 0`)
+	assert.Contains(t, text, `hhchecker_cert_expiry_timestamp_seconds{target="up"} `)
+	assert.Contains(t, text, `hhchecker_notifications_total{provider="slack",result="success"} 0`)
+	assert.Contains(t, text, `hhchecker_notifications_total{provider="slack",result="failure"} `)
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/theshamuel/hhchecker/app/checker"
+	"net/http"
+	"time"
+)
+
+var reportContentTypes = map[string]string{
+	checker.ReportText: "text/plain; charset=utf-8",
+	checker.ReportCSV:  "text/csv; charset=utf-8",
+	checker.ReportJSON: "application/json; charset=utf-8",
+}
+
+// report returns uptime of targets for the period of from and to query params in the format param, json by default.
+// The target param limits the report to the named targets and can be repeated.
+func (s *Server) report(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from, to, err := checker.ParsePeriod(q.Get("from"), q.Get("to"), time.Now())
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	format := q.Get("format")
+	if format == "" {
+		format = checker.ReportJSON
+	}
+	contentType, ok := reportContentTypes[format]
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "format is not one of text, csv, json"})
+		return
+	}
+
+	names, known := q["target"], map[string]bool{}
+	for _, t := range s.Scheduler.Targets {
+		known[t.Name] = true
+	}
+	for _, name := range names {
+		if !known[name] {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": fmt.Sprintf("target %q is not found", name)})
+			return
+		}
+	}
+	reports := s.Scheduler.Reports(from, to)
+	if len(names) > 0 {
+		filtered := reports[:0]
+		for _, rep := range reports {
+			for _, name := range names {
+				if rep.Target == name {
+					filtered = append(filtered, rep)
+					break
+				}
+			}
+		}
+		reports = filtered
+	}
+	var buf bytes.Buffer
+	if err = checker.WriteReports(&buf, format, from, to, reports); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(buf.Bytes())
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/theshamuel/hhchecker/app/checker"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// history is the stored history of targets
+type history map[string][]checker.Day
+
+func (h history) Load(target string) ([]checker.Day, []checker.Incident, error) {
+	return h[target], nil, nil
+}
+
+func (h history) Record(string, checker.Result) error { return nil }
+
+func (h history) Incident(checker.Incident) error { return nil }
+
+func TestReport(t *testing.T) {
+	date := time.Date(2026, 9, 15, 0, 0, 0, 0, time.UTC)
+	s := &Server{Scheduler: &checker.Scheduler{
+		Targets: []checker.Target{{Name: "api"}, {Name: "blog"}},
+		History: history{"api": {{Date: date, Checks: 1000, Failed: 10}}},
+	}}
+	ts := httptest.NewServer(s.routes())
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/api/v1/report?from=2026-09-01&to=2026-10-01")
+	assert.NoError(t, err)
+	assert.Equal(t, "application/json; charset=utf-8", res.Header.Get("Content-Type"))
+	var rep struct {
+		From    time.Time `json:"from"`
+		Targets []struct {
+			Target string  `json:"target"`
+			Uptime float64 `json:"uptime_percent"`
+		} `json:"targets"`
+	}
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&rep))
+	res.Body.Close()
+	assert.Equal(t, time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), rep.From)
+	assert.Len(t, rep.Targets, 2)
+	assert.Equal(t, "api", rep.Targets[0].Target)
+	assert.Equal(t, float64(99), rep.Targets[0].Uptime)
+
+	res, err = http.Get(ts.URL + "/api/v1/report?from=2026-09-01&to=2026-10-01&format=csv&target=blog")
+	assert.NoError(t, err)
+	body, err := io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, "text/csv; charset=utf-8", res.Header.Get("Content-Type"))
+	assert.Equal(t, 2, strings.Count(string(body), "\n"))
+	assert.Contains(t, string(body), "blog,2026-09-01T00:00:00Z,2026-10-01T00:00:00Z,100.000,0,0,0,0,0")
+
+	res, err = http.Get(ts.URL + "/api/v1/report?target=blog&target=web")
+	assert.NoError(t, err)
+	body, err = io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	assert.Equal(t, `{"error":"target \"web\" is not found"}`+"\n", string(body))
+
+	for _, query := range []string{"format=xml", "from=2026-10-01&to=2026-09-01", "to=tomorrow"} {
+		res, err = http.Get(ts.URL + "/api/v1/report?" + query)
+		assert.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, query)
+	}
+}
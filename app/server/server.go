@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/theshamuel/hhchecker/app/checker"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Server is the http api of hhchecker, jobs push heartbeats to /ping/<token>,
+// the current state of targets is served by /api/v1/status, uptime reports by /api/v1/report
+// and Prometheus metrics by /metrics
+type Server struct {
+	Address    string // the address of the api, disabled if empty
+	Scheduler  *checker.Scheduler
+	Version    string
+	StartedAt  time.Time   // the start of the process for uptime, the start of the server if zero
+	StatusPage *StatusPage // the public page on /status and its JSON feed on /status.json, disabled if nil
+}
+
+// Run listens on the address and the own address of the status page if set,
+// and shuts the servers down when ctx is done
+func (s *Server) Run(ctx context.Context) error {
+	if s.StartedAt.IsZero() {
+		s.StartedAt = time.Now()
+	}
+	errs := make(chan error, 2)
+	servers := 0
+	if s.Address != "" {
+		servers++
+		go func() { errs <- serve(ctx, "http server", s.Address, s.routes()) }()
+	}
+	if s.StatusPage != nil && s.StatusPage.Listen != "" {
+		servers++
+		go func() { errs <- serve(ctx, "status page", s.StatusPage.Listen, s.pageRoutes(http.NewServeMux())) }()
+	}
+	for i := 0; i < servers; i++ {
+		if err := <-errs; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serve listens on the address until ctx is done
+func serve(ctx context.Context, name, addr string, h http.Handler) error {
+	srv := &http.Server{Addr: addr, Handler: h, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("[WARN] %s shutdown error: %v", name, err)
+		}
+	}()
+	log.Printf("[INFO] %s listens on %s", name, addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%s failed: %w", name, err)
+	}
+	return nil
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ping/", s.ping)
+	mux.HandleFunc("/api/v1/status", getOnly(s.status))
+	mux.HandleFunc("/api/v1/status/", getOnly(s.targetStatus))
+	mux.HandleFunc("/api/v1/report", getOnly(s.report))
+	mux.HandleFunc("/metrics", getOnly(s.metrics))
+	if s.StatusPage != nil && s.StatusPage.Listen == "" {
+		s.pageRoutes(mux)
+	}
+	return mux
+}
+
+// pageRoutes adds the public status page to mux, the page listener has nothing else
+func (s *Server) pageRoutes(mux *http.ServeMux) http.Handler {
+	mux.HandleFunc("/status", getOnly(s.statusHTML))
+	mux.HandleFunc("/status.json", getOnly(s.statusJSON))
+	return mux
+}
+
+// ping records the heartbeat of the token from the path, any method is accepted to keep cron jobs simple
+func (s *Server) ping(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.URL.Path, "/ping/")
+	name, ok := s.Scheduler.Beat(token, time.Now())
+	if !ok {
+		http.Error(w, "heartbeat token is not found", http.StatusNotFound)
+		return
+	}
+	log.Printf("[DEBUG] heartbeat of target %s is received from %s", name, r.RemoteAddr)
+	_, _ = w.Write([]byte("OK\n"))
+}
+
+// getOnly rejects all methods except GET and HEAD
+func getOnly(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		h(w, r)
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/theshamuel/hhchecker/app/checker"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPing(t *testing.T) {
+	s := &Server{Scheduler: &checker.Scheduler{Targets: []checker.Target{
+		{Name: "backup", Type: checker.TypeHeartbeat, Heartbeat: checker.HeartbeatCheck{Token: "b4ck", Period: time.Hour}},
+	}}}
+	ts := httptest.NewServer(s.routes())
+	defer ts.Close()
+
+	res, err := http.Post(ts.URL+"/ping/b4ck", "text/plain", nil)
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "OK\n", string(body))
+
+	res, err = http.Get(ts.URL + "/ping/unknown")
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestRun(t *testing.T) {
+	addr := freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- (&Server{Address: addr, Scheduler: &checker.Scheduler{}}).Run(ctx) }()
+	assert.Eventually(t, func() bool {
+		res, err := http.Get("http://" + addr + "/ping/x")
+		if err != nil {
+			return false
+		}
+		res.Body.Close()
+		return res.StatusCode == http.StatusNotFound
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestRunStatusPageListen(t *testing.T) {
+	addr, pageAddr := freeAddr(t), freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	s := &Server{Address: addr, Scheduler: &checker.Scheduler{}, StatusPage: &StatusPage{Title: "Status", Listen: pageAddr}}
+	go func() { done <- s.Run(ctx) }()
+	get := func(url string) int {
+		res, err := http.Get(url)
+		if err != nil {
+			return 0
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+	assert.Eventually(t, func() bool { return get("http://"+pageAddr+"/status") == http.StatusOK }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, http.StatusOK, get("http://"+pageAddr+"/status.json"))
+	for _, path := range []string{"/api/v1/status", "/api/v1/report", "/metrics", "/ping/x"} {
+		assert.Equal(t, http.StatusNotFound, get("http://"+pageAddr+path), "page listener has no "+path)
+	}
+	assert.Equal(t, http.StatusNotFound, get("http://"+addr+"/status"), "page is not served by the api")
+	assert.Equal(t, http.StatusOK, get("http://"+addr+"/api/v1/status"))
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func freeAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
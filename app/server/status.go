@@ -0,0 +1,115 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/theshamuel/hhchecker/app/checker"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type statusResponse struct {
+	Version   string           `json:"version"`
+	StartedAt time.Time        `json:"started_at"`
+	UptimeSec int64            `json:"uptime_sec"`
+	Targets   []targetResponse `json:"targets"`
+}
+
+type targetResponse struct {
+	Name          string         `json:"name"`
+	Type          string         `json:"type"`
+	URL           string         `json:"url,omitempty"`
+	State         string         `json:"state"`
+	Consecutive   int            `json:"consecutive_failures"`
+	Checks        int            `json:"checks"`
+	Failed        int            `json:"failed_checks"`
+	Uptime        float64        `json:"uptime_percent"`
+	WatchingSince time.Time      `json:"watching_since"`
+	DownSince     *time.Time     `json:"down_since,omitempty"`
+	LastAlert     *time.Time     `json:"last_alert,omitempty"`
+	LastProbe     *probeResponse `json:"last_probe,omitempty"`
+}
+
+type probeResponse struct {
+	Time       time.Time  `json:"time"`
+	OK         bool       `json:"ok"`
+	StatusCode int        `json:"status_code,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+	LatencyMs  int64      `json:"latency_ms"`
+	CertExpiry *time.Time `json:"cert_expiry,omitempty"`
+}
+
+// status returns all targets
+func (s *Server) status(w http.ResponseWriter, r *http.Request) {
+	res := statusResponse{
+		Version:   s.Version,
+		StartedAt: s.StartedAt,
+		UptimeSec: int64(time.Since(s.StartedAt).Seconds()),
+		Targets:   []targetResponse{},
+	}
+	for _, st := range s.Scheduler.Statuses() {
+		res.Targets = append(res.Targets, targetStatus(st))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// targetStatus returns the single target by name from the path
+func (s *Server) targetStatus(w http.ResponseWriter, r *http.Request) {
+	name, err := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), "/api/v1/status/"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	st, ok := s.Scheduler.Status(name)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "target is not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, targetStatus(st))
+}
+
+func targetStatus(st checker.Status) targetResponse {
+	res := targetResponse{
+		Name:          st.Name,
+		Type:          st.Type,
+		URL:           st.URL,
+		State:         st.State,
+		Consecutive:   st.Consecutive,
+		Checks:        st.Checks,
+		Failed:        st.Failed,
+		Uptime:        st.Uptime(),
+		WatchingSince: st.WatchingSince,
+		DownSince:     optionalTime(st.DownSince),
+		LastAlert:     optionalTime(st.LastAlert),
+	}
+	if st.Checks > 0 {
+		res.LastProbe = &probeResponse{
+			Time:       st.Last.Time,
+			OK:         st.Last.OK(),
+			StatusCode: st.Last.StatusCode,
+			Reason:     st.Last.Reason(),
+			LatencyMs:  st.Last.Latency.Milliseconds(),
+		}
+		if st.Last.Cert != nil {
+			res.LastProbe.CertExpiry = optionalTime(st.Last.Cert.NotAfter)
+		}
+	}
+	return res
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("[WARN] can't write json response: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/theshamuel/hhchecker/app/checker"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStatus(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer backend.Close()
+	scheduler := &checker.Scheduler{
+		Targets: []checker.Target{{Name: "web site", URL: backend.URL, Interval: 10 * time.Millisecond, MaxAlerts: 1}},
+		Client:  backend.Client(),
+	}
+	runScheduler(t, scheduler)
+
+	s := &Server{Scheduler: scheduler, Version: "test", StartedAt: time.Now().Add(-time.Minute)}
+	ts := httptest.NewServer(s.routes())
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/api/v1/status")
+	assert.NoError(t, err)
+	var all statusResponse
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&all))
+	res.Body.Close()
+	assert.Equal(t, "application/json; charset=utf-8", res.Header.Get("Content-Type"))
+	assert.Equal(t, "test", all.Version)
+	assert.GreaterOrEqual(t, all.UptimeSec, int64(60))
+	assert.Len(t, all.Targets, 1)
+	assert.Equal(t, "web site", all.Targets[0].Name)
+	assert.Equal(t, checker.StatusUp, all.Targets[0].State)
+	assert.Equal(t, float64(100), all.Targets[0].Uptime)
+	assert.True(t, all.Targets[0].LastProbe.OK)
+	assert.Equal(t, http.StatusOK, all.Targets[0].LastProbe.StatusCode)
+	assert.Nil(t, all.Targets[0].LastAlert)
+
+	res, err = http.Get(ts.URL + "/api/v1/status/web%20site")
+	assert.NoError(t, err)
+	var one targetResponse
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&one))
+	res.Body.Close()
+	assert.Equal(t, "web site", one.Name)
+	assert.Equal(t, "http", one.Type)
+
+	res, err = http.Get(ts.URL + "/api/v1/status/unknown")
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	res, err = http.Post(ts.URL+"/api/v1/status", "application/json", nil)
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+}
+
+// runScheduler runs the scheduler until the test end and waits for the first probe of every target
+func runScheduler(t *testing.T, scheduler *checker.Scheduler) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	for _, target := range scheduler.Targets {
+		assert.Eventually(t, func() bool {
+			st, ok := scheduler.Status(target.Name)
+			return ok && st.Checks > 0
+		}, time.Second, 5*time.Millisecond)
+	}
+}
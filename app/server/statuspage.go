@@ -0,0 +1,242 @@
+package server
+
+import (
+	"fmt"
+	"github.com/theshamuel/hhchecker/app/checker"
+	"html/template"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// StatusPage is the public page of components, target urls and failure reasons are not shown
+type StatusPage struct {
+	Title      string
+	Listen     string      // the own address of the page without the api, the page is served by the api server if empty
+	Components []Component // every target is own component if empty
+}
+
+// Component groups the targets under the public name
+type Component struct {
+	Name    string
+	Targets []string
+}
+
+// component and overall states from the best to the worst
+const (
+	pageOperational   = "operational"
+	pageUnknown       = "unknown"
+	pageDegraded      = "degraded"
+	pagePartialOutage = "partial_outage"
+	pageMajorOutage   = "major_outage"
+)
+
+var pageStateRank = map[string]int{pageOperational: 0, pageUnknown: 1, pageDegraded: 2, pagePartialOutage: 3, pageMajorOutage: 4}
+
+type feed struct {
+	Title      string          `json:"title"`
+	State      string          `json:"state"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	Components []feedComponent `json:"components"`
+	Incidents  []feedIncident  `json:"incidents"`
+}
+
+type feedComponent struct {
+	Name   string    `json:"name"`
+	State  string    `json:"state"`
+	Uptime float64   `json:"uptime_percent"`
+	Days   []feedDay `json:"days"`
+}
+
+type feedDay struct {
+	Date   string   `json:"date"`
+	Uptime *float64 `json:"uptime_percent"` // null without probes
+}
+
+type feedIncident struct {
+	Component   string     `json:"component"`
+	StartedAt   time.Time  `json:"started_at"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+	DurationSec int64      `json:"duration_sec"`
+}
+
+// components returns the configured components or a component per target
+func (p *StatusPage) components(statuses []checker.Status) []Component {
+	if len(p.Components) > 0 {
+		return p.Components
+	}
+	res := make([]Component, 0, len(statuses))
+	for _, st := range statuses {
+		res = append(res, Component{Name: st.Name, Targets: []string{st.Name}})
+	}
+	return res
+}
+
+// feed makes the state of components with daily uptime for the last checker.HistoryDays days
+func (p *StatusPage) feed(statuses []checker.Status, now time.Time) feed {
+	byName := map[string]checker.Status{}
+	for _, st := range statuses {
+		byName[st.Name] = st
+	}
+	res := feed{Title: p.Title, State: pageOperational, UpdatedAt: now, Components: []feedComponent{}, Incidents: []feedIncident{}}
+	today := now.UTC().Truncate(24 * time.Hour)
+	for _, c := range p.components(statuses) {
+		fc := feedComponent{Name: c.Name, Uptime: 100}
+		days := map[time.Time]checker.Day{}
+		var targets []checker.Status
+		for _, name := range c.Targets {
+			st, ok := byName[name]
+			if !ok {
+				continue
+			}
+			targets = append(targets, st)
+			for _, d := range st.Days {
+				sum := days[d.Date]
+				sum.Checks, sum.Failed = sum.Checks+d.Checks, sum.Failed+d.Failed
+				days[d.Date] = sum
+			}
+			for _, inc := range st.Incidents {
+				res.Incidents = append(res.Incidents, incident(c.Name, inc, now))
+			}
+		}
+		fc.State = componentState(targets)
+		total := checker.Day{}
+		for i := checker.HistoryDays - 1; i >= 0; i-- {
+			date := today.AddDate(0, 0, -i)
+			fd := feedDay{Date: date.Format("2006-01-02")}
+			if d, ok := days[date]; ok && d.Checks > 0 {
+				uptime := d.Uptime()
+				fd.Uptime = &uptime
+				total.Checks, total.Failed = total.Checks+d.Checks, total.Failed+d.Failed
+			}
+			fc.Days = append(fc.Days, fd)
+		}
+		fc.Uptime = total.Uptime()
+		if pageStateRank[fc.State] > pageStateRank[res.State] {
+			res.State = fc.State
+		}
+		res.Components = append(res.Components, fc)
+	}
+	sort.SliceStable(res.Incidents, func(i, j int) bool { return res.Incidents[i].StartedAt.After(res.Incidents[j].StartedAt) })
+	return res
+}
+
+func incident(component string, inc checker.Incident, now time.Time) feedIncident {
+	res := feedIncident{Component: component, StartedAt: inc.Started, ResolvedAt: optionalTime(inc.Resolved)}
+	end := now
+	if res.ResolvedAt != nil {
+		end = inc.Resolved
+	}
+	res.DurationSec = int64(end.Sub(inc.Started).Seconds())
+	return res
+}
+
+// componentState is major outage if all targets are down and partial outage if any
+func componentState(targets []checker.Status) string {
+	down, failing, pending := 0, 0, 0
+	for _, st := range targets {
+		switch st.State {
+		case checker.StatusDown:
+			down++
+		case checker.StatusFailing:
+			failing++
+		case checker.StatusPending:
+			pending++
+		}
+	}
+	switch {
+	case len(targets) == 0 || pending == len(targets):
+		return pageUnknown
+	case down == len(targets):
+		return pageMajorOutage
+	case down > 0:
+		return pagePartialOutage
+	case failing > 0:
+		return pageDegraded
+	}
+	return pageOperational
+}
+
+// statusJSON is the JSON feed of the status page
+func (s *Server) statusJSON(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.StatusPage.feed(s.Scheduler.Statuses(), time.Now()))
+}
+
+// statusHTML renders the status page with the last 10 incidents
+func (s *Server) statusHTML(w http.ResponseWriter, r *http.Request) {
+	f := s.StatusPage.feed(s.Scheduler.Statuses(), time.Now())
+	if len(f.Incidents) > 10 {
+		f.Incidents = f.Incidents[:10]
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := statusPageTemplate.Execute(w, f); err != nil {
+		log.Printf("[WARN] can't render status page: %v", err)
+	}
+}
+
+var statusPageTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"label": func(state string) string {
+		return map[string]string{
+			pageOperational:   "Operational",
+			pageUnknown:       "Unknown",
+			pageDegraded:      "Degraded performance",
+			pagePartialOutage: "Partial outage",
+			pageMajorOutage:   "Major outage",
+		}[state]
+	},
+	"bar": func(d feedDay) string {
+		switch {
+		case d.Uptime == nil:
+			return "none"
+		case *d.Uptime >= 99.9:
+			return pageOperational
+		case *d.Uptime >= 95:
+			return pageDegraded
+		}
+		return pageMajorOutage
+	},
+	"deref":    func(v *float64) float64 { return *v },
+	"percent":  func(v float64) string { return fmt.Sprintf("%.2f%%", v) },
+	"duration": func(sec int64) string { return (time.Duration(sec) * time.Second).String() },
+}).Parse(statusPageHTML))
+
+const statusPageHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta http-equiv="refresh" content="60">
+<title>{{.Title}}</title>
+<style>
+body{font-family:-apple-system,Helvetica,Arial,sans-serif;max-width:860px;margin:0 auto;padding:24px;color:#222}
+.banner{padding:16px;border-radius:6px;color:#fff;font-weight:bold;margin-bottom:24px}
+.component{border:1px solid #e3e3e3;border-radius:6px;padding:16px;margin-bottom:12px}
+.head{display:flex;justify-content:space-between;margin-bottom:8px}
+.bars{display:flex;gap:2px;height:32px}
+.bars span{flex:1;border-radius:2px}
+.legend{display:flex;justify-content:space-between;color:#888;font-size:12px;margin-top:4px}
+.operational{background:#2eb886}.unknown,.none{background:#c8c8c8}.degraded{background:#f2c744}
+.partial_outage{background:#f08a24}.major_outage{background:#d00000}
+.state.operational,.state.unknown,.state.degraded,.state.partial_outage,.state.major_outage{background:none}
+.state.operational{color:#2eb886}.state.unknown{color:#888}.state.degraded{color:#c99a00}
+.state.partial_outage{color:#f08a24}.state.major_outage{color:#d00000}
+li{margin-bottom:6px}
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="banner {{.State}}">{{if eq .State "operational"}}All systems operational{{else}}{{label .State}}{{end}}</div>
+{{range .Components}}<div class="component">
+<div class="head"><strong>{{.Name}}</strong><span class="state {{.State}}">{{label .State}}</span></div>
+<div class="bars">{{range .Days}}<span class="{{bar .}}" title="{{.Date}}{{if .Uptime}} {{percent (deref .Uptime)}}{{else}} no data{{end}}"></span>{{end}}</div>
+<div class="legend"><span>90 days ago</span><span>{{percent .Uptime}} uptime</span><span>today</span></div>
+</div>
+{{end}}<h2>Recent incidents</h2>
+{{if .Incidents}}<ul>{{range .Incidents}}<li><strong>{{.Component}}</strong> {{.StartedAt.Format "2006-01-02 15:04 MST"}},
+{{if .ResolvedAt}}resolved after {{duration .DurationSec}}{{else}}ongoing for {{duration .DurationSec}}{{end}}</li>{{end}}</ul>
+{{else}}<p>No incidents.</p>{{end}}
+<p class="legend">Updated {{.UpdatedAt.Format "2006-01-02 15:04:05 MST"}}</p>
+</body>
+</html>
+`
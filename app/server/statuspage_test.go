@@ -0,0 +1,94 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/theshamuel/hhchecker/app/checker"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStatusPageFeed(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	today, yesterday := now.Truncate(24*time.Hour), now.Truncate(24*time.Hour).AddDate(0, 0, -1)
+	statuses := []checker.Status{
+		{Name: "web", State: checker.StatusUp, Days: []checker.Day{{Date: yesterday, Checks: 10}, {Date: today, Checks: 10}}},
+		{Name: "api", State: checker.StatusDown, Days: []checker.Day{{Date: today, Checks: 10, Failed: 5}},
+			Incidents: []checker.Incident{
+				{Target: "api", Started: now.Add(-48 * time.Hour), Resolved: now.Add(-47 * time.Hour), Reason: "secret"},
+				{Target: "api", Started: now.Add(-time.Hour), Reason: "internal 10.0.0.1 timeout"},
+			}},
+		{Name: "db", State: checker.StatusPending},
+	}
+	page := &StatusPage{Title: "Status", Components: []Component{
+		{Name: "Website", Targets: []string{"web", "api"}},
+		{Name: "Database", Targets: []string{"db"}},
+	}}
+
+	f := page.feed(statuses, now)
+	assert.Equal(t, "Status", f.Title)
+	assert.Equal(t, pagePartialOutage, f.State)
+	assert.Len(t, f.Components, 2)
+
+	website := f.Components[0]
+	assert.Equal(t, pagePartialOutage, website.State)
+	assert.InDelta(t, 83.33, website.Uptime, 0.01)
+	assert.Len(t, website.Days, checker.HistoryDays)
+	assert.Equal(t, "2026-03-10", website.Days[checker.HistoryDays-1].Date)
+	assert.Equal(t, float64(75), *website.Days[checker.HistoryDays-1].Uptime)
+	assert.Equal(t, float64(100), *website.Days[checker.HistoryDays-2].Uptime)
+	assert.Nil(t, website.Days[0].Uptime)
+
+	assert.Equal(t, pageUnknown, f.Components[1].State)
+	assert.Equal(t, float64(100), f.Components[1].Uptime)
+
+	assert.Len(t, f.Incidents, 2)
+	assert.Equal(t, feedIncident{Component: "Website", StartedAt: now.Add(-time.Hour), DurationSec: 3600}, f.Incidents[0], "newest first")
+	assert.Equal(t, int64(3600), f.Incidents[1].DurationSec)
+	assert.NotNil(t, f.Incidents[1].ResolvedAt)
+
+	html := &bytes.Buffer{}
+	assert.NoError(t, statusPageTemplate.Execute(html, f))
+	assert.Contains(t, html.String(), `<span class="state partial_outage">Partial outage</span>`)
+	assert.Contains(t, html.String(), `title="2026-03-10 75.00%"`)
+	assert.Contains(t, html.String(), "ongoing for 1h0m0s")
+	assert.NotContains(t, html.String(), "10.0.0.1", "failure reasons are not public")
+
+	page = &StatusPage{Title: "All"}
+	f = page.feed(statuses, now)
+	assert.Len(t, f.Components, 3, "every target is own component")
+	assert.Equal(t, pageMajorOutage, f.Components[1].State)
+	assert.Equal(t, pageMajorOutage, f.State)
+}
+
+func TestStatusPageRoutes(t *testing.T) {
+	s := &Server{Scheduler: &checker.Scheduler{}}
+	ts := httptest.NewServer(s.routes())
+	res, err := http.Get(ts.URL + "/status")
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode, "status page is disabled")
+	ts.Close()
+
+	s.StatusPage = &StatusPage{Title: "Theshamuel <status>"}
+	ts = httptest.NewServer(s.routes())
+	defer ts.Close()
+	res, err = http.Get(ts.URL + "/status")
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Equal(t, "text/html; charset=utf-8", res.Header.Get("Content-Type"))
+	assert.Contains(t, string(body), "<title>Theshamuel &lt;status&gt;</title>")
+	assert.Contains(t, string(body), "All systems operational")
+
+	res, err = http.Get(ts.URL + "/status.json")
+	assert.NoError(t, err)
+	var f feed
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&f))
+	res.Body.Close()
+	assert.Equal(t, pageOperational, f.State)
+}
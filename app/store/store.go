@@ -0,0 +1,354 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/theshamuel/hhchecker/app/checker"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// default retention of the history
+const (
+	DefaultRetention    = checker.HistoryDays * 24 * time.Hour
+	DefaultRawRetention = 24 * time.Hour
+	compactInterval     = time.Hour
+)
+
+// kinds of the record
+const (
+	kindProbe    = "probe"
+	kindDay      = "day"
+	kindIncident = "incident"
+)
+
+// Store keeps probe results and incidents in the append-only JSON lines file. Probes older than RawRetention
+// are downsampled into daily aggregates and everything older than Retention is dropped by compaction.
+type Store struct {
+	Path         string
+	Retention    time.Duration // DefaultRetention if 0
+	RawRetention time.Duration // DefaultRawRetention if 0
+
+	mu          sync.Mutex
+	file        *os.File
+	days        map[string]map[time.Time]*checker.Day
+	incidents   map[string][]checker.Incident
+	lastCompact time.Time
+}
+
+// record is the single line of the file
+type record struct {
+	Kind       string     `json:"kind"`
+	Target     string     `json:"target"`
+	Time       time.Time  `json:"time"`
+	OK         bool       `json:"ok,omitempty"`
+	StatusCode int        `json:"status_code,omitempty"`
+	LatencyMs  int64      `json:"latency_ms,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+	Checks     int        `json:"checks,omitempty"`
+	Failed     int        `json:"failed,omitempty"`
+	Resolved   *time.Time `json:"resolved,omitempty"` // nil for the open incident
+}
+
+func (r record) resolved() time.Time {
+	if r.Resolved == nil {
+		return time.Time{}
+	}
+	return *r.Resolved
+}
+
+func resolved(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// Open loads the history from the file, compacts it and opens the file for appending
+func Open(path string, retention, rawRetention time.Duration) (*Store, error) {
+	s := &Store{Path: path, Retention: retention, RawRetention: rawRetention}
+	if s.Retention <= 0 {
+		s.Retention = DefaultRetention
+	}
+	if s.RawRetention <= 0 {
+		s.RawRetention = DefaultRawRetention
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.compact(time.Now()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Read loads the history from the file without compaction, the returned store can't record.
+// It is safe to read the file used by running checker.
+func Read(path string) (*Store, error) {
+	s := &Store{Path: path}
+	records, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	s.days, s.incidents = history(records)
+	return s, nil
+}
+
+// Close closes the file
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// Load returns the daily probe counts and incidents of the target, the oldest first
+func (s *Store) Load(target string) ([]checker.Day, []checker.Incident, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	days := make([]checker.Day, 0, len(s.days[target]))
+	for _, d := range s.days[target] {
+		days = append(days, *d)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date.Before(days[j].Date) })
+	return days, append([]checker.Incident(nil), s.incidents[target]...), nil
+}
+
+// Targets returns names of all targets in the history, sorted
+func (s *Store) Targets() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := map[string]bool{}
+	for name := range s.days {
+		names[name] = true
+	}
+	for name := range s.incidents {
+		names[name] = true
+	}
+	res := make([]string, 0, len(names))
+	for name := range names {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Record appends the probe result
+func (s *Store) Record(target string, r checker.Result) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec := record{Kind: kindProbe, Target: target, Time: r.Time, OK: r.OK(), StatusCode: r.StatusCode,
+		LatencyMs: r.Latency.Milliseconds(), Reason: r.Reason()}
+	s.apply(rec)
+	if err := s.append(rec); err != nil {
+		return err
+	}
+	if time.Since(s.lastCompact) > compactInterval {
+		return s.compact(time.Now())
+	}
+	return nil
+}
+
+// Incident appends the opened or resolved incident, the incident is identified by target and start
+func (s *Store) Incident(i checker.Incident) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec := record{Kind: kindIncident, Target: i.Target, Time: i.Started, Reason: i.Reason, Resolved: resolved(i.Resolved)}
+	s.apply(rec)
+	return s.append(rec)
+}
+
+// history applies the records in order to the empty history
+func history(records []record) (map[string]map[time.Time]*checker.Day, map[string][]checker.Incident) {
+	h := &Store{days: map[string]map[time.Time]*checker.Day{}, incidents: map[string][]checker.Incident{}}
+	for _, rec := range records {
+		h.apply(rec)
+	}
+	return h.days, h.incidents
+}
+
+// apply adds the record into the loaded history
+func (s *Store) apply(rec record) {
+	switch rec.Kind {
+	case kindProbe, kindDay:
+		date := rec.Time.UTC().Truncate(24 * time.Hour)
+		if s.days[rec.Target] == nil {
+			s.days[rec.Target] = map[time.Time]*checker.Day{}
+		}
+		d := s.days[rec.Target][date]
+		if d == nil {
+			d = &checker.Day{Date: date}
+			s.days[rec.Target][date] = d
+		}
+		if rec.Kind == kindDay {
+			d.Checks, d.Failed = d.Checks+rec.Checks, d.Failed+rec.Failed
+			return
+		}
+		d.Checks++
+		if !rec.OK {
+			d.Failed++
+		}
+	case kindIncident:
+		incidents := s.incidents[rec.Target]
+		for i := range incidents {
+			if incidents[i].Started.Equal(rec.Time) {
+				incidents[i].Resolved, incidents[i].Reason = rec.resolved(), rec.Reason
+				return
+			}
+		}
+		s.incidents[rec.Target] = append(incidents, checker.Incident{Target: rec.Target, Started: rec.Time,
+			Resolved: rec.resolved(), Reason: rec.Reason})
+	}
+}
+
+func (s *Store) append(rec record) error {
+	if s.file == nil {
+		return fmt.Errorf("store %s is closed", s.Path)
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+// compact reloads the file, downsamples old probes, drops expired records and rewrites the file atomically.
+// The file handle and the loaded history are replaced only after the rewrite, the store keeps appending
+// to the current file if compaction fails. The failed compaction is retried after compactInterval.
+func (s *Store) compact(now time.Time) error {
+	s.lastCompact = now
+	records, err := s.read()
+	if err != nil {
+		return err
+	}
+	_, incidents := history(records) // the open and resolve records of the same incident are merged
+
+	rawSince, since := now.Add(-s.RawRetention), now.Add(-s.Retention).UTC().Truncate(24*time.Hour)
+	var kept []record
+	// raw probes are kept as is, older ones are counted into the day records of the same date
+	downsampled := map[string]map[time.Time]*checker.Day{}
+	for _, rec := range records {
+		if rec.Kind != kindProbe && rec.Kind != kindDay {
+			continue
+		}
+		date := rec.Time.UTC().Truncate(24 * time.Hour)
+		if date.Before(since) {
+			continue
+		}
+		if rec.Kind == kindProbe && !rec.Time.Before(rawSince) {
+			kept = append(kept, rec)
+			continue
+		}
+		if downsampled[rec.Target] == nil {
+			downsampled[rec.Target] = map[time.Time]*checker.Day{}
+		}
+		d := downsampled[rec.Target][date]
+		if d == nil {
+			d = &checker.Day{Date: date}
+			downsampled[rec.Target][date] = d
+		}
+		switch {
+		case rec.Kind == kindDay:
+			d.Checks, d.Failed = d.Checks+rec.Checks, d.Failed+rec.Failed
+		case rec.OK:
+			d.Checks++
+		default:
+			d.Checks, d.Failed = d.Checks+1, d.Failed+1
+		}
+	}
+	for target, days := range downsampled {
+		for _, d := range days {
+			kept = append(kept, record{Kind: kindDay, Target: target, Time: d.Date, Checks: d.Checks, Failed: d.Failed})
+		}
+	}
+	for target, list := range incidents {
+		for _, i := range list {
+			if !i.Resolved.IsZero() && i.Resolved.Before(since) {
+				continue
+			}
+			kept = append(kept, record{Kind: kindIncident, Target: target, Time: i.Started, Reason: i.Reason, Resolved: resolved(i.Resolved)})
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].Time.Before(kept[j].Time) })
+
+	f, err := s.write(kept)
+	if err != nil {
+		return err
+	}
+	if s.file != nil {
+		if err = s.file.Close(); err != nil {
+			log.Printf("[WARN] can't close replaced store %s: %v", s.Path, err)
+		}
+	}
+	s.file = f
+	s.days, s.incidents = history(kept)
+	return nil
+}
+
+// read returns all records of the file, the missing file is empty history
+func (s *Store) read() ([]record, error) {
+	f, err := os.Open(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't open store %s: %w", s.Path, err)
+	}
+	defer f.Close()
+	var records []record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		var rec record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// the line can be partially written on crash, it is dropped by compaction
+			log.Printf("[WARN] store %s line %d is skipped: %v", s.Path, n, err)
+			continue
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("can't read store %s: %w", s.Path, err)
+	}
+	return records, nil
+}
+
+// write replaces the file by the records through the temporary file and returns the new file open for appending
+func (s *Store) write(records []record) (*os.File, error) {
+	tmp := s.Path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("can't write store %s: %w", tmp, err)
+	}
+	if err = writeRecords(f, records); err == nil {
+		err = os.Rename(tmp, s.Path) // the open file follows the rename
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return nil, fmt.Errorf("can't write store %s: %w", tmp, err)
+	}
+	return f, nil
+}
+
+func writeRecords(f *os.File, records []record) error {
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Sync()
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/theshamuel/hhchecker/app/checker"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoreSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	s, err := Open(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	assert.NoError(t, s.Record("api", checker.Result{StatusCode: 200, Time: now.Add(-time.Minute)}))
+	assert.NoError(t, s.Record("api", checker.Result{StatusCode: 500, Err: errors.New("bad status code 500"), Time: now}))
+	assert.NoError(t, s.Record("web", checker.Result{StatusCode: 200, Time: now}))
+	assert.NoError(t, s.Incident(checker.Incident{Target: "api", Started: now, Reason: "bad status code 500"}))
+	assert.NoError(t, s.Incident(checker.Incident{Target: "api", Started: now, Resolved: now.Add(time.Minute), Reason: "bad status code 500"}))
+	assert.NoError(t, s.Close())
+	assert.Error(t, s.Record("api", checker.Result{Time: now}), "closed store")
+
+	s, err = Open(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	days, incidents, err := s.Load("api")
+	assert.NoError(t, err)
+	assert.Len(t, days, 1)
+	assert.Equal(t, 2, days[0].Checks)
+	assert.Equal(t, 1, days[0].Failed)
+	assert.Len(t, incidents, 1, "the open and resolve records are merged")
+	assert.True(t, incidents[0].Resolved.Equal(now.Add(time.Minute)))
+
+	days, incidents, err = s.Load("unknown")
+	assert.NoError(t, err)
+	assert.Empty(t, days)
+	assert.Empty(t, incidents)
+}
+
+func TestStoreCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	now := time.Now().UTC()
+	old, expired := now.AddDate(0, 0, -3), now.AddDate(0, 0, -100)
+	lines := []string{
+		`{"kind":"probe","target":"api","time":"` + old.Format(time.RFC3339Nano) + `","ok":true}`,
+		`{"kind":"probe","target":"api","time":"` + old.Add(time.Second).Format(time.RFC3339Nano) + `"}`,
+		`{"kind":"day","target":"api","time":"` + old.Truncate(24*time.Hour).Format(time.RFC3339Nano) + `","checks":8}`,
+		`{"kind":"probe","target":"api","time":"` + expired.Format(time.RFC3339Nano) + `","ok":true}`,
+		`{"kind":"incident","target":"api","time":"` + expired.Format(time.RFC3339Nano) + `","resolved":"` + expired.Add(time.Hour).Format(time.RFC3339Nano) + `"}`,
+		`{"kind":"incident","target":"api","time":"` + old.Format(time.RFC3339Nano) + `"}`,
+		`{"kind":"probe","target":"api","time":"` + now.Format(time.RFC3339Nano) + `","ok":true}`,
+		`{"kind":"probe","tar`,
+	}
+	assert.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600))
+
+	s, err := Open(path, 30*24*time.Hour, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	days, incidents, err := s.Load("api")
+	assert.NoError(t, err)
+	assert.Equal(t, []checker.Day{
+		{Date: old.Truncate(24 * time.Hour), Checks: 10, Failed: 1},
+		{Date: now.Truncate(24 * time.Hour), Checks: 1},
+	}, days)
+	assert.Len(t, incidents, 1, "the expired incident is dropped")
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(data), "\n"), "day, open incident and raw probe are kept")
+	assert.Equal(t, 1, strings.Count(string(data), `"kind":"probe"`))
+	assert.Equal(t, 1, strings.Count(string(data), `"kind":"day"`))
+}
+
+func TestStoreCompactionFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	s, err := Open(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	now := time.Now()
+	assert.NoError(t, s.Record("api", checker.Result{StatusCode: 200, Time: now}))
+
+	assert.NoError(t, os.Mkdir(path+".tmp", 0o700), "the temporary file can't be created")
+	assert.Error(t, s.compact(now))
+	assert.NoError(t, s.Record("api", checker.Result{StatusCode: 200, Time: now}), "the store appends after failed compaction")
+	assert.NoError(t, s.Incident(checker.Incident{Target: "api", Started: now}))
+
+	assert.NoError(t, os.Remove(path+".tmp"))
+	assert.NoError(t, s.compact(now))
+	assert.NoError(t, s.Record("api", checker.Result{StatusCode: 200, Time: now}))
+	assert.NoError(t, s.Close())
+
+	r, err := Read(path)
+	assert.NoError(t, err)
+	days, incidents, err := r.Load("api")
+	assert.NoError(t, err)
+	assert.Equal(t, []checker.Day{{Date: now.UTC().Truncate(24 * time.Hour), Checks: 3}}, days)
+	assert.Len(t, incidents, 1)
+}
+
+func TestRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	s, err := Open(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	assert.NoError(t, s.Record("web", checker.Result{StatusCode: 200, Time: time.Now()}))
+	assert.NoError(t, s.Incident(checker.Incident{Target: "api", Started: time.Now()}))
+
+	r, err := Read(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"api", "web"}, r.Targets())
+	days, _, err := r.Load("web")
+	assert.NoError(t, err)
+	assert.Len(t, days, 1)
+	assert.Error(t, r.Record("web", checker.Result{Time: time.Now()}), "read only")
+	assert.NoError(t, r.Close())
+	assert.NoError(t, s.Record("web", checker.Result{StatusCode: 200, Time: time.Now()}), "the file is still open by store")
+}
@@ -7,11 +7,13 @@ require (
 	github.com/stretchr/testify v1.7.0
 	github.com/theshamuel/go-flags v1.5.2
 	go.uber.org/goleak v1.1.12
+	golang.org/x/net v0.30.0
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/sys v0.6.0 // indirect
+	golang.org/x/sys v0.26.0 // indirect
+	golang.org/x/text v0.19.0 // indirect
 )